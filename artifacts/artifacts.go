@@ -0,0 +1,36 @@
+package artifacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	baseDir string = filepath.Join(".neat", "artifacts")
+	runID   string = "latest"
+)
+
+//Init sets where artifacts are stored, each run gets its own directory named by its id
+func Init(dir string, id string) {
+	if dir != "" {
+		baseDir = dir
+	}
+	if id != "" {
+		runID = id
+	}
+}
+
+//RunDir gives the artifact directory for the current run without creating it
+func RunDir() string {
+	return filepath.Join(baseDir, runID)
+}
+
+//Dir gives the path to a directory within the current run's artifact directory, creating it if needed
+func Dir(elem ...string) (string, error) {
+	dir := filepath.Join(append([]string{RunDir()}, elem...)...)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	return dir, nil
+}
@@ -1,25 +1,35 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/willfantom/neat/artifacts"
 	"github.com/willfantom/neat/testbeds"
 	"github.com/willfantom/neat/tests"
+	"gopkg.in/yaml.v2"
 )
 
 type Compose struct {
-	Testbeds []*testbeds.Testbed `mapstructure:"testbeds"`
-	Tests    []*tests.Test       `mapstructure:"tests"`
+	Templates map[string]*testbeds.Testbed `mapstructure:"-" json:"templates,omitempty"`
+	Testbeds  []*testbeds.Testbed          `mapstructure:"testbeds" json:"testbeds"`
+	Tests     []*tests.Test                `mapstructure:"tests" json:"tests"`
 }
 
-var uiLock = sync.Mutex{}
+var (
+	uiLock       = sync.Mutex{}
+	artifactsDir string
+)
 
 var (
 	composeCmd = &cobra.Command{
@@ -30,47 +40,80 @@ var (
 			if err != nil {
 				logrus.WithField("extended", err.Error()).Fatalln("failed to parse compose file")
 			}
+			artifacts.Init(artifactsDir, testbeds.RunID())
 
 			err = addTestbeds(compose.Testbeds)
 			if err != nil {
 				logrus.WithField("extended", err.Error()).Fatalln("failed to add testbed")
 			}
 
-			start := time.Now()
-
-			err = createTestbeds(compose.Testbeds)
+			err = validateTests(compose.Tests)
 			if err != nil {
-				logrus.WithField("extended", err.Error()).Fatalln("failed to create/start testbeds")
+				logrus.WithField("extended", err.Error()).Fatalln("failed to validate test")
 			}
 
-			seenFailure := false
+			ctx, cancel := handleSignals()
+			defer cancel()
 
-			for _, test := range compose.Tests {
-				success, err := test.RunValid()
-				if err != nil {
-					logrus.WithField("extended", err.Error()).Fatalln("test failed")
-				}
-				if success {
-					uiTestPassed(test.Name)
-				} else {
-					uiTestFailed(test.Name)
-					seenFailure = true
+			start := time.Now()
+			testFailure := false
+			infrastructureFailure := false
+
+			err = createTestbeds(ctx, compose.Testbeds)
+			if err != nil {
+				logrus.WithField("extended", err.Error()).Errorln("failed to create/start testbeds")
+				infrastructureFailure = true
+			} else {
+				saveTopologies(compose.Testbeds)
+				for _, test := range compose.Tests {
+					if ctx.Err() != nil {
+						fmt.Printf("Run Interrupted: skipping remaining tests\n")
+						break
+					}
+					success, err := test.RunValid(ctx)
+					if err != nil {
+						logrus.WithField("extended", err.Error()).Errorln("test failed")
+					}
+					if success {
+						uiTestPassed(test.Name)
+					} else {
+						uiTestFailed(test.Name)
+						testFailure = true
+					}
+					for _, testbedName := range test.TestbedNames {
+						for _, path := range test.Metrics[testbedName].Artifacts {
+							uiTestArtifact(test.Name, path)
+						}
+					}
 				}
 			}
 
-			err = removeTestbeds(compose.Testbeds)
+			err = removeTestbeds(context.Background(), compose.Testbeds)
 			if err != nil {
-				logrus.WithField("extended", err.Error()).Fatalln("failed to stop/remove testbeds")
+				logrus.WithField("extended", err.Error()).Errorln("failed to stop/remove testbeds")
+				infrastructureFailure = true
 			}
 
 			fmt.Printf("Total Time: %d\n", time.Since(start).Milliseconds())
 
-			dumpStats(compose.Testbeds)
+			if err := saveSamples(compose.Testbeds); err != nil {
+				logrus.WithField("extended", err.Error()).Warnln("failed to save testbed resource samples")
+			}
+			if err := saveEvents(compose.Testbeds); err != nil {
+				logrus.WithField("extended", err.Error()).Warnln("failed to save testbed event timelines")
+			}
 
-			if seenFailure {
-				os.Exit(1)
-			} else {
-				os.Exit(0)
+			dumpStats(compose.Testbeds, compose.Tests)
+
+			switch {
+			case ctx.Err() != nil:
+				os.Exit(exitInterrupted)
+			case infrastructureFailure:
+				os.Exit(exitInfrastructureFailure)
+			case testFailure:
+				os.Exit(exitTestFailure)
+			default:
+				os.Exit(exitSuccess)
 			}
 
 		},
@@ -89,67 +132,168 @@ func parseComposeFile() (*Compose, error) {
 	}
 
 	var compose Compose
-	if err := composeViper.Unmarshal(&compose); err != nil {
+	if err := composeViper.Unmarshal(&compose, viper.DecodeHook(composeDecodeHook())); err != nil {
+		return nil, err
+	}
+	templates, err := parseTemplates(composeViper.ConfigFileUsed())
+	if err != nil {
+		return nil, err
+	}
+	compose.Templates = templates
+	composeDir, err := filepath.Abs(filepath.Dir(composeViper.ConfigFileUsed()))
+	if err != nil {
+		return nil, err
+	}
+	for _, testbed := range compose.Testbeds {
+		testbed.SetDir(composeDir)
+	}
+	for _, test := range compose.Tests {
+		test.SetDir(composeDir)
+	}
+	if err := testbeds.ResolveTemplates(compose.Templates, compose.Testbeds); err != nil {
 		return nil, err
 	}
 	return &compose, nil
 }
 
+//parseTemplates decodes the templates from the compose file itself, viper lowercases and splits
+//on dots the keys of any map it reads, which would mangle the keys of templates' variant configs
+func parseTemplates(path string) (map[string]*testbeds.Testbed, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Templates map[string]interface{} `yaml:"templates"`
+	}
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+	templates := make(map[string]*testbeds.Testbed)
+	for name, spec := range file.Templates {
+		var template testbeds.Testbed
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       composeDecodeHook(),
+			WeaklyTypedInput: true,
+			Result:           &template,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(spec); err != nil {
+			return nil, fmt.Errorf("template '%s' is not valid: %w", name, err)
+		}
+		templates[name] = &template
+	}
+	return templates, nil
+}
+
+//composeDecodeHook converts the compose file's values into the types used by testbeds and tests
+func composeDecodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		testbeds.ResourceCapHookFunc(),
+	)
+}
+
 func addTestbeds(allTestbeds []*testbeds.Testbed) error {
 	for _, testbed := range allTestbeds {
 		fmt.Printf("Adding Testbed: %s\n", testbed.Name)
 		if _, err := testbed.Add(); err != nil {
+			return fmt.Errorf("testbed '%s' is not valid: %w\nresolved spec:\n%s", testbed.Name, err, renderSpec(testbed))
+		}
+	}
+	return nil
+}
+
+func validateTests(allTests []*tests.Test) error {
+	for _, test := range allTests {
+		if valid, err := test.Validate(); !valid && err == nil {
+			return fmt.Errorf("test '%s' configuration is not valid", test.Name)
+		} else if !valid {
 			return err
 		}
 	}
 	return nil
 }
 
-func createTestbeds(allTestbeds []*testbeds.Testbed) error {
+//createTestbeds creates and starts all testbeds concurrently, if any fail the others are cancelled
+//and every testbed that was created is rolled back
+func createTestbeds(ctx context.Context, allTestbeds []*testbeds.Testbed) error {
+	createCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	wg := sync.WaitGroup{}
+	errs := testbedErrors{}
 	for _, testbed := range allTestbeds {
+		if createCtx.Err() != nil {
+			break
+		}
 		fmt.Printf("Creating Testbed: %s\n", testbed.Name)
 		wg.Add(1)
 		go func(testbed *testbeds.Testbed) {
 			defer wg.Done()
-			if err := testbeds.Create(testbed.Name); err != nil {
+			if err := testbeds.Create(createCtx, testbed.Name); err != nil {
 				fmt.Printf("Failed to Create Testbed: %s\n", testbed.Name)
-				panic(err)
+				errs.add(testbed.Name, "create", err)
+				cancel()
+				return
 			}
 			uiTestbedCreated(testbed.Name)
-			if err := testbeds.Start(testbed.Name); err != nil {
+			if err := testbeds.Start(createCtx, testbed.Name); err != nil {
 				fmt.Printf("Failed to Start Testbed: %s\n", testbed.Name)
-				panic(err)
+				errs.add(testbed.Name, "start", err)
+				cancel()
+				return
 			}
 			uiTestbedStarted(testbed.Name)
 		}(testbed)
 		time.Sleep(100 * time.Millisecond)
 	}
 	wg.Wait()
-	return nil
+	if errs.err() == nil && ctx.Err() != nil {
+		errs.merge(ctx.Err())
+	}
+	if errs.err() != nil {
+		fmt.Printf("Rolling Back Testbeds\n")
+		errs.merge(removeTestbeds(context.Background(), allTestbeds))
+	}
+	return errs.err()
 }
 
-func removeTestbeds(allTestbeds []*testbeds.Testbed) error {
+//removeTestbeds stops and removes every testbed that was created, continuing past failures so that
+//as much as possible is cleaned up
+func removeTestbeds(ctx context.Context, allTestbeds []*testbeds.Testbed) error {
 	wg := sync.WaitGroup{}
+	errs := testbedErrors{}
 	for _, testbed := range allTestbeds {
+		if !testbed.Created() {
+			continue
+		}
 		wg.Add(1)
 		go func(testbed *testbeds.Testbed) {
 			defer wg.Done()
-			fmt.Printf("Stopping Testbed: %s\n", testbed.Name)
-			if err := testbeds.Stop(testbed.Name); err != nil {
-				fmt.Printf("Failed to Stop Testbed: %s\n", testbed.Name)
-				panic(err)
+			if testbed.Started() {
+				fmt.Printf("Stopping Testbed: %s\n", testbed.Name)
+				if err := testbeds.Stop(ctx, testbed.Name); err != nil {
+					fmt.Printf("Failed to Stop Testbed: %s\n", testbed.Name)
+					errs.add(testbed.Name, "stop", err)
+				}
 			}
-			if err := testbeds.Remove(testbed.Name); err != nil {
+			if err := testbeds.Remove(ctx, testbed.Name); err != nil {
 				fmt.Printf("Failed to Remove Testbed: %s\n", testbed.Name)
-				panic(err)
+				errs.add(testbed.Name, "remove", err)
+			} else {
+				fmt.Printf("Removed Testbed: %s\n", testbed.Name)
+			}
+			for _, path := range testbed.Metrics.Artifacts {
+				uiTestbedArtifact(testbed.Name, path)
 			}
-			fmt.Printf("Stopped Testbed: %s\n", testbed.Name)
 		}(testbed)
 		time.Sleep(100 * time.Millisecond)
 	}
 	wg.Wait()
-	return nil
+	return errs.err()
 }
 
 func uiTestbedCreated(tbName string) {
@@ -164,6 +308,12 @@ func uiTestbedStarted(tbName string) {
 	fmt.Printf("\n✅\tTestbed Started: %s\n", strings.ToLower(tbName))
 }
 
+func uiTestbedArtifact(tbName string, path string) {
+	uiLock.Lock()
+	defer uiLock.Unlock()
+	fmt.Printf("\n📄\tTestbed Artifact: %s: %s\n", strings.ToLower(tbName), path)
+}
+
 func uiTestPassed(tName string) {
 	uiLock.Lock()
 	defer uiLock.Unlock()
@@ -176,19 +326,43 @@ func uiTestFailed(tName string) {
 	fmt.Printf("\n❌\tTest Failed: %s\n", strings.ToLower(tName))
 }
 
-func dumpStats(allTestbeds []*testbeds.Testbed) {
+func uiTestArtifact(tName string, path string) {
+	uiLock.Lock()
+	defer uiLock.Unlock()
+	fmt.Printf("\n📄\tTest Artifact: %s: %s\n", strings.ToLower(tName), path)
+}
+
+func dumpStats(allTestbeds []*testbeds.Testbed, allTests []*tests.Test) {
 	for _, testbed := range allTestbeds {
 		fmt.Printf("----------\nTestbed %s\n", testbed.Name)
 		fmt.Printf("\tCreated %s\n", testbed.Metrics.CreatedAt.Format("15:04:05.0000"))
+		if testbed.Metrics.ImageDigest != "" {
+			fmt.Printf("\tImage %s\n", testbed.Metrics.ImageDigest)
+		}
 		fmt.Printf("\tRemoved %s\n", testbed.Metrics.RemovedAt.Format("15:04:05.0000"))
-		fmt.Printf("\tStart Time %dms\n", testbed.Metrics.Runs[0].StartTime.Milliseconds())
 		fmt.Printf("\tTotal Time %dms\n", testbed.Metrics.RemovedAt.Sub(testbed.Metrics.CreatedAt).Milliseconds())
+		if events := testbed.Events(); len(events) > 0 {
+			fmt.Printf("\tEvents\n")
+			for _, event := range events {
+				fmt.Printf("\t\t%s %s %s %s\n", event.Time.Format("15:04:05.0000"), event.Type, event.Subject, event.Detail)
+			}
+		}
+		if len(testbed.Metrics.Runs) == 0 {
+			fmt.Printf("----------\n")
+			continue
+		}
+		fmt.Printf("\tStart Time %dms\n", testbed.Metrics.Runs[0].StartTime.Milliseconds())
 		fmt.Printf("\tCPU Usage %f pct\n", testbed.Metrics.Runs[0].CPUUsage)
 		fmt.Printf("\tMemory Usage %f pct\n", testbed.Metrics.Runs[0].PeakMemoryUsage)
+		if peakCPU, peakMemory := peakSamples(testbed.Metrics.Runs[0]); peakCPU != nil {
+			fmt.Printf("\tPeak CPU Usage %f pct at %s (%s)\n", peakCPU.CPUUsage, peakCPU.Time.Format("15:04:05.0000"), attributeSample(peakCPU, testbed.Name, allTests))
+			fmt.Printf("\tPeak Memory Usage %f pct at %s (%s)\n", peakMemory.MemoryUsage, peakMemory.Time.Format("15:04:05.0000"), attributeSample(peakMemory, testbed.Name, allTests))
+		}
 		fmt.Printf("----------\n")
 	}
 }
 
 func init() {
+	composeCmd.Flags().StringVar(&artifactsDir, "artifacts-dir", "./.neat/artifacts", "directory to store run artifacts in (within a directory per run)")
 	rootCmd.AddCommand(composeCmd)
 }
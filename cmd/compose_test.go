@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/willfantom/neat/testbeds"
+)
+
+//lifecycleRecorder is a testbed variant that records the lifecycle steps run against each testbed
+type lifecycleRecorder struct {
+	lock  sync.Mutex
+	steps map[string][]string
+}
+
+func (r *lifecycleRecorder) record(testbed *testbeds.Testbed, step string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.steps[testbed.Name] = append(r.steps[testbed.Name], step)
+}
+
+func (r *lifecycleRecorder) stepsFor(tbName string) []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string{}, r.steps[tbName]...)
+}
+
+//variant starts testbeds with the given behaviour, testbeds with no behaviour start immediately
+func (r *lifecycleRecorder) variant(name string, starts map[string]func(ctx context.Context) error) testbeds.Variant {
+	step := func(step string) func(ctx context.Context, testbed *testbeds.Testbed) error {
+		return func(ctx context.Context, testbed *testbeds.Testbed) error {
+			r.record(testbed, step)
+			return nil
+		}
+	}
+	return testbeds.Variant{
+		Name: name,
+		ValidateConfiguration: func(testbed *testbeds.Testbed) (bool, error) {
+			return true, nil
+		},
+		Create: step("create"),
+		Start: func(ctx context.Context, testbed *testbeds.Testbed) error {
+			r.record(testbed, "start")
+			if start, ok := starts[testbed.Name]; ok {
+				return start(ctx)
+			}
+			return nil
+		},
+		Stop:   step("stop"),
+		Remove: step("remove"),
+		HookArguments: func(path string, testbed *testbeds.Testbed) []string {
+			return []string{path}
+		},
+	}
+}
+
+func TestCreateTestbedsRollsBackStartingSiblings(t *testing.T) {
+	//testbeds are registered globally so each run needs its own names
+	suffix := fmt.Sprintf("-%d", time.Now().UnixNano())
+	started, starting, failing := "rollback-started"+suffix, "rollback-starting"+suffix, "rollback-failing"+suffix
+
+	recorder := &lifecycleRecorder{steps: make(map[string][]string)}
+	testbeds.Variants["rollback-test"] = recorder.variant("rollback-test", map[string]func(ctx context.Context) error{
+		starting: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		failing: func(ctx context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return fmt.Errorf("failed to start")
+		},
+	})
+	defer delete(testbeds.Variants, "rollback-test")
+
+	allTestbeds := []*testbeds.Testbed{
+		{Name: started, VariantName: "rollback-test"},
+		{Name: starting, VariantName: "rollback-test"},
+		{Name: failing, VariantName: "rollback-test"},
+	}
+	if err := addTestbeds(allTestbeds); err != nil {
+		t.Fatalf("failed to add testbeds: %v", err)
+	}
+
+	if err := createTestbeds(context.Background(), allTestbeds); err == nil {
+		t.Fatalf("expected creation to fail")
+	}
+
+	expected := map[string][]string{
+		started:  {"create", "start", "stop", "remove"},
+		starting: {"create", "start", "remove"},
+		failing:  {"create", "start", "remove"},
+	}
+	for _, testbed := range allTestbeds {
+		if testbed.Created() || testbed.Started() {
+			t.Errorf("testbed '%s' was not torn down", testbed.Name)
+		}
+		if steps := recorder.stepsFor(testbed.Name); fmt.Sprint(steps) != fmt.Sprint(expected[testbed.Name]) {
+			t.Errorf("testbed '%s' ran %v, expected %v", testbed.Name, steps, expected[testbed.Name])
+		}
+	}
+}
+
+func TestParseComposeFileKeepsTemplateConfigKeys(t *testing.T) {
+	compose := `
+templates:
+  Base:
+    variant: mtv
+    sample_interval: 2s
+    config:
+      environment:
+        LICENSE_SERVER: licenses.example.com
+      volumes:
+        ./images/disk.qcow2: /images/disk.qcow2
+testbeds:
+  - name: tb1
+    extends: base
+    config:
+      environment:
+        DEBUG: "1"
+`
+	parsed := parseCompose(t, compose)
+	testbed := parsed.Testbeds[0]
+	if testbed.Extends != "" {
+		t.Errorf("extends was not cleared after resolution")
+	}
+	if testbed.VariantName != "mtv" || testbed.SampleInterval.Seconds() != 2 {
+		t.Errorf("template fields were not inherited: %+v", testbed)
+	}
+	environment, ok := testbed.VariantConfig["environment"].(map[string]interface{})
+	if !ok || environment["LICENSE_SERVER"] != "licenses.example.com" || environment["DEBUG"] != "1" {
+		t.Errorf("environment was not merged with its keys intact: %v", testbed.VariantConfig["environment"])
+	}
+	volumes, ok := testbed.VariantConfig["volumes"].(map[string]interface{})
+	if !ok || volumes["./images/disk.qcow2"] != "/images/disk.qcow2" {
+		t.Errorf("volumes were not inherited with their keys intact: %v", testbed.VariantConfig["volumes"])
+	}
+}
+
+func TestParseComposeFileAcceptsBooleanResourceCap(t *testing.T) {
+	compose := `
+templates:
+  capped:
+    resource_cap: true
+testbeds:
+  - name: tb1
+    resource_cap: false
+  - name: tb2
+    resource_cap: true
+  - name: tb3
+    extends: capped
+  - name: tb4
+    resource_cap:
+      memory: 512m
+`
+	parsed := parseCompose(t, compose)
+	if resourceCap := parsed.Testbeds[0].ResourceCap; resourceCap == nil || resourceCap.Applies() {
+		t.Errorf("resource_cap: false should give a disabled resource cap, got %+v", resourceCap)
+	}
+	for _, testbed := range parsed.Testbeds[1:3] {
+		if testbed.ResourceCap == nil || *testbed.ResourceCap != (testbeds.ResourceCap{}) {
+			t.Errorf("testbed '%s' resource_cap: true should give an empty resource cap, got %+v", testbed.Name, testbed.ResourceCap)
+		}
+	}
+	if resourceCap := parsed.Testbeds[3].ResourceCap; resourceCap == nil || resourceCap.Memory != "512m" {
+		t.Errorf("resource_cap limits were not decoded: %+v", resourceCap)
+	}
+}
+
+func TestParseComposeFileOptsOutOfTemplateResourceCap(t *testing.T) {
+	compose := `
+templates:
+  capped:
+    resource_cap:
+      memory: 512m
+testbeds:
+  - name: tb1
+    extends: capped
+    resource_cap: false
+  - name: tb2
+    extends: capped
+`
+	parsed := parseCompose(t, compose)
+	if resourceCap := parsed.Testbeds[0].ResourceCap; resourceCap.Applies() {
+		t.Errorf("the template's resource cap should not apply once disabled, got %+v", resourceCap)
+	}
+	if resourceCap := parsed.Testbeds[1].ResourceCap; !resourceCap.Applies() || resourceCap.Memory != "512m" {
+		t.Errorf("the template's resource cap was not inherited, got %+v", resourceCap)
+	}
+}
+
+//parseCompose parses the given compose file from within a temporary directory
+func parseCompose(t *testing.T, compose string) *Compose {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "neat-compose.yaml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	parsed, err := parseComposeFile()
+	if err != nil {
+		t.Fatalf("failed to parse compose file: %v", err)
+	}
+	return parsed
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	configFormat string
+
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "View the NEAT Compose spec with all templates resolved, without validating it",
+		Run: func(cmd *cobra.Command, args []string) {
+			compose, err := parseComposeFile()
+			if err != nil {
+				logrus.WithField("extended", err.Error()).Fatalln("failed to parse compose file")
+			}
+			spec, err := specMap(compose)
+			if err != nil {
+				logrus.WithField("extended", err.Error()).Fatalln("failed to render compose file")
+			}
+			if resolved, ok := spec.(map[string]interface{}); ok {
+				delete(resolved, "templates")
+				for _, section := range []string{"testbeds", "tests"} {
+					if entries, ok := resolved[section].([]interface{}); ok {
+						for _, entry := range entries {
+							stripMetrics(entry)
+						}
+					}
+				}
+			}
+			output, err := formatSpec(spec, configFormat)
+			if err != nil {
+				logrus.WithField("extended", err.Error()).Fatalln("failed to render compose file")
+			}
+			fmt.Print(output)
+		},
+	}
+)
+
+//renderSpec gives a yaml representation of a single resolved testbed for use in error messages
+func renderSpec(v interface{}) string {
+	spec, err := specMap(v)
+	if err != nil {
+		return err.Error()
+	}
+	stripMetrics(spec)
+	output, err := formatSpec(spec, "yaml")
+	if err != nil {
+		return err.Error()
+	}
+	return output
+}
+
+//specMap converts the given value into a generic map using its json field names
+func specMap(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var spec interface{}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func stripMetrics(spec interface{}) {
+	if entry, ok := spec.(map[string]interface{}); ok {
+		delete(entry, "metrics")
+	}
+}
+
+func formatSpec(spec interface{}, format string) (string, error) {
+	switch format {
+	case "yaml":
+		output, err := yaml.Marshal(spec)
+		if err != nil {
+			return "", err
+		}
+		return string(output), nil
+	case "json":
+		output, err := json.MarshalIndent(spec, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(output) + "\n", nil
+	default:
+		return "", fmt.Errorf("output format '%s' is not supported", format)
+	}
+}
+
+func init() {
+	configCmd.Flags().StringVarP(&configFormat, "format", "f", "yaml", "output format (yaml or json)")
+	rootCmd.AddCommand(configCmd)
+}
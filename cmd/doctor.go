@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/tools"
+	"github.com/willfantom/neat/tools/docker"
+)
+
+var (
+	doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check which tools and testbed variants are available and why",
+		Run: func(cmd *cobra.Command, args []string) {
+			variantNames := make([]string, 0, len(testbeds.Variants))
+			for name := range testbeds.Variants {
+				variantNames = append(variantNames, name)
+			}
+			sort.Strings(variantNames)
+
+			//the default container engine is always reported, even though testbeds may use their own
+			allTools := []tools.Tool{docker.Tool}
+			seenTools := map[string]bool{docker.Tool.Name: true}
+			for _, name := range variantNames {
+				for _, tool := range testbeds.Variants[name].Tools {
+					if !seenTools[tool.Name] {
+						seenTools[tool.Name] = true
+						allTools = append(allTools, tool)
+					}
+				}
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "TOOL\tSTATUS\tVERSION\tDETAILS")
+			for _, tool := range allTools {
+				status := tool.Check()
+				details := tool.Description
+				if !status.Available {
+					details = status.Reason
+					if status.Remediation != "" {
+						details = fmt.Sprintf("%s (hint: %s)", details, status.Remediation)
+					}
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", tool.Name, uiAvailability(status.Available), status.Version, details)
+			}
+			fmt.Fprintln(writer)
+
+			fmt.Fprintln(writer, "VARIANT\tSTATUS\tCAPABILITIES\tDETAILS")
+			for _, name := range variantNames {
+				variant := testbeds.Variants[name]
+				capabilities := make([]string, 0)
+				for _, capability := range variant.Capabilities() {
+					capabilities = append(capabilities, string(capability))
+				}
+				sort.Strings(capabilities)
+				details := variant.Description
+				err := variant.Available()
+				if err != nil {
+					details = err.Error()
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", name, uiAvailability(err == nil), strings.Join(capabilities, ","), details)
+			}
+			writer.Flush()
+		},
+	}
+)
+
+func uiAvailability(available bool) string {
+	if available {
+		return "✅ available"
+	}
+	return "❌ unavailable"
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
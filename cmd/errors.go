@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	exitSuccess               int = 0
+	exitTestFailure           int = 1
+	exitInfrastructureFailure int = 2
+	exitInterrupted           int = 130
+)
+
+//testbedErrors collects the failures of concurrent testbed operations so they can be reported together
+type testbedErrors struct {
+	lock sync.Mutex
+	errs []error
+}
+
+func (e *testbedErrors) add(tbName string, step string, err error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.errs = append(e.errs, fmt.Errorf("failed to %s testbed '%s': %w", step, tbName, err))
+}
+
+func (e *testbedErrors) merge(err error) {
+	if err == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if multi, ok := err.(multiError); ok {
+		e.errs = append(e.errs, multi...)
+	} else {
+		e.errs = append(e.errs, err)
+	}
+}
+
+func (e *testbedErrors) err() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return multiError(append([]error{}, e.errs...))
+}
+
+type multiError []error
+
+func (m multiError) Error() string {
+	if len(m) == 1 {
+		return m[0].Error()
+	}
+	messages := make([]string, len(m))
+	for idx, err := range m {
+		messages[idx] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred:\n\t%s", len(m), strings.Join(messages, "\n\t"))
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/willfantom/neat/artifacts"
+	"github.com/willfantom/neat/testbeds"
+)
+
+//saveEvents writes each testbed's event timeline into the run's artifacts as json
+func saveEvents(allTestbeds []*testbeds.Testbed) error {
+	for _, testbed := range allTestbeds {
+		events := testbed.Events()
+		if len(events) == 0 {
+			continue
+		}
+		dir, err := artifacts.Dir("testbeds", testbed.Name)
+		if err != nil {
+			return err
+		}
+		output, err := json.MarshalIndent(events, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, "events.json")
+		if err := os.WriteFile(path, append(output, '\n'), 0644); err != nil {
+			return err
+		}
+		testbed.AddArtifact(path)
+		uiTestbedArtifact(testbed.Name, path)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/willfantom/neat/artifacts"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/types"
+)
+
+func TestSaveEvents(t *testing.T) {
+	dir := t.TempDir()
+	artifacts.Init(dir, "run")
+	quiet := &testbeds.Testbed{Name: "quiet"}
+	busy := &testbeds.Testbed{Name: "busy"}
+	busy.RecordEvent(types.Event{Time: time.Now(), Type: "ready"})
+	busy.RecordEvent(types.Event{Time: time.Now(), Type: "link_down", Subject: "h1-s1"})
+
+	if err := saveEvents([]*testbeds.Testbed{quiet, busy}); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+	if len(quiet.Metrics.Artifacts) != 0 {
+		t.Errorf("no timeline should be saved for a testbed without events")
+	}
+	path := filepath.Join(dir, "run", "testbeds", "busy", "events.json")
+	if len(busy.Metrics.Artifacts) != 1 || busy.Metrics.Artifacts[0] != path {
+		t.Fatalf("timeline was not added as an artifact: %v", busy.Metrics.Artifacts)
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []types.Event
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		t.Fatalf("timeline is not valid json: %v", err)
+	}
+	if len(saved) != 2 || saved[1].Subject != "h1-s1" {
+		t.Errorf("unexpected saved timeline: %+v", saved)
+	}
+}
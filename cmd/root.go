@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/willfantom/neat/testbeds/plugin"
+	"github.com/willfantom/neat/tools/docker"
 )
 
 var (
-	logLevel string
+	logLevel   string
+	pluginDir  string
+	engineHost string
 
 	rootCmd = &cobra.Command{
 		Use:   "neat",
@@ -19,6 +25,10 @@ var (
 					logrus.SetLevel(logrus_level)
 				}
 			}
+			docker.SetDefaultHost(engineHost)
+			if err := plugin.Discover(pluginDir); err != nil {
+				logrus.WithField("extended", err.Error()).Fatalln("failed to load testbed variant plugins")
+			}
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
@@ -32,4 +42,6 @@ func Execute() error {
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "v", "info", "logging level")
+	rootCmd.PersistentFlags().StringVar(&pluginDir, "plugin-dir", "./.neat/plugins", "directory to load testbed variant plugins from")
+	rootCmd.PersistentFlags().StringVar(&engineHost, "engine-host", os.Getenv("NEAT_ENGINE_HOST"), "container engine endpoint (e.g. unix:///run/user/1000/podman/podman.sock), defaults to DOCKER_HOST or a detected docker/podman socket")
 }
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/willfantom/neat/artifacts"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/tests"
+)
+
+//saveSamples writes each testbed's resource usage time series into the run's artifacts as csv
+func saveSamples(allTestbeds []*testbeds.Testbed) error {
+	for _, testbed := range allTestbeds {
+		for runIdx, run := range testbed.Metrics.Runs {
+			if len(run.Samples) == 0 {
+				continue
+			}
+			dir, err := artifacts.Dir("testbeds", testbed.Name)
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(dir, fmt.Sprintf("samples-%d.csv", runIdx))
+			if err := writeSamples(path, run.Samples); err != nil {
+				return err
+			}
+			testbed.AddArtifact(path)
+			uiTestbedArtifact(testbed.Name, path)
+		}
+	}
+	return nil
+}
+
+func writeSamples(path string, samples []testbeds.ResourceSample) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"time", "cpu_usage", "memory_usage"}); err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		if err := writer.Write([]string{
+			sample.Time.Format(time.RFC3339Nano),
+			strconv.FormatFloat(sample.CPUUsage, 'f', 4, 64),
+			strconv.FormatFloat(sample.MemoryUsage, 'f', 4, 64),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+//peakSamples finds the samples with the highest cpu and memory usage in a run
+func peakSamples(run testbeds.RunMetrics) (cpu *testbeds.ResourceSample, memory *testbeds.ResourceSample) {
+	for idx := range run.Samples {
+		sample := &run.Samples[idx]
+		if cpu == nil || sample.CPUUsage > cpu.CPUUsage {
+			cpu = sample
+		}
+		if memory == nil || sample.MemoryUsage > memory.MemoryUsage {
+			memory = sample
+		}
+	}
+	return cpu, memory
+}
+
+//attributeSample gives the name of the test that was running on the testbed when the sample was taken
+func attributeSample(sample *testbeds.ResourceSample, testbedName string, allTests []*tests.Test) string {
+	for _, test := range allTests {
+		metrics, ok := test.Metrics[testbedName]
+		if !ok {
+			continue
+		}
+		if !sample.Time.Before(metrics.StartedAt) && !sample.Time.After(metrics.StartedAt.Add(metrics.ExecutionTime)) {
+			return test.Name
+		}
+	}
+	return "no test"
+}
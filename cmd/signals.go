@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+//handleSignals gives a context that is cancelled by the first interrupt or terminate signal so that
+//testbeds can be torn down, a second signal exits immediately
+func handleSignals() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Printf("\nReceived %s: tearing down testbeds (signal again to force exit)\n", sig)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(signals)
+			return
+		}
+		sig := <-signals
+		fmt.Printf("\nReceived %s: forcing exit, testbeds may not have been removed\n", sig)
+		os.Exit(exitInterrupted)
+	}()
+	return ctx, cancel
+}
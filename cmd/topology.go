@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/willfantom/neat/artifacts"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/topology"
+)
+
+var (
+	topologyFormat string
+
+	topologyCmd = &cobra.Command{
+		Use:   "topology <testbed>",
+		Short: "Create a testbed from the NEAT Compose spec and export its network topology",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			compose, err := parseComposeFile()
+			if err != nil {
+				logrus.WithField("extended", err.Error()).Fatalln("failed to parse compose file")
+			}
+			artifacts.Init(artifactsDir, testbeds.RunID())
+			var testbed *testbeds.Testbed
+			for _, candidate := range compose.Testbeds {
+				if candidate.Name == args[0] {
+					testbed = candidate
+				}
+			}
+			if testbed == nil {
+				logrus.Fatalf("testbed '%s' is not in the compose file", args[0])
+			}
+			if _, err := testbed.Add(); err != nil {
+				logrus.WithField("extended", err.Error()).Fatalln("failed to add testbed")
+			}
+			if err := testbed.Require(testbeds.CapabilityTopology); err != nil {
+				logrus.WithField("extended", err.Error()).Fatalln("testbed can not export its topology")
+			}
+
+			ctx, cancel := handleSignals()
+			defer cancel()
+			output, err := exportTopology(ctx, testbed)
+			if teardownErr := teardownTestbed(context.Background(), testbed); teardownErr != nil {
+				logrus.WithField("extended", teardownErr.Error()).Errorln("failed to stop/remove testbed")
+			}
+			if err != nil {
+				logrus.WithField("extended", err.Error()).Fatalln("failed to export topology")
+			}
+			fmt.Print(output)
+		},
+	}
+)
+
+//exportTopology creates and starts the testbed and renders its topology, the caller must remove the testbed
+func exportTopology(ctx context.Context, testbed *testbeds.Testbed) (string, error) {
+	if err := testbeds.Create(ctx, testbed.Name); err != nil {
+		return "", err
+	}
+	if err := testbeds.Start(ctx, testbed.Name); err != nil {
+		return "", err
+	}
+	graph, err := testbedGraph(testbed)
+	if err != nil {
+		return "", err
+	}
+	return graph.Render(testbed.Name, topologyFormat)
+}
+
+//teardownTestbed stops and removes the testbed without writing progress to stdout, which holds the topology
+func teardownTestbed(ctx context.Context, testbed *testbeds.Testbed) error {
+	errs := testbedErrors{}
+	if testbed.Started() {
+		if err := testbeds.Stop(ctx, testbed.Name); err != nil {
+			errs.add(testbed.Name, "stop", err)
+		}
+	}
+	if testbed.Created() {
+		if err := testbeds.Remove(ctx, testbed.Name); err != nil {
+			errs.add(testbed.Name, "remove", err)
+		}
+	}
+	return errs.err()
+}
+
+func testbedGraph(testbed *testbeds.Testbed) (*topology.Graph, error) {
+	testbedTopology, err := testbed.Topology()
+	if err != nil {
+		return nil, err
+	}
+	return topology.New(*testbedTopology)
+}
+
+//saveTopologies stores the topology of every started testbed that can describe one as a dot file in
+//the run's artifacts, failures are only logged as they do not affect the run
+func saveTopologies(allTestbeds []*testbeds.Testbed) {
+	for _, testbed := range allTestbeds {
+		if !testbed.Started() || !testbed.Supports(testbeds.CapabilityTopology) {
+			continue
+		}
+		logger := logrus.WithField("testbed", testbed.Name)
+		graph, err := testbedGraph(testbed)
+		if err != nil {
+			logger.WithField("extended", err.Error()).Warnln("failed to get testbed topology")
+			continue
+		}
+		dir, err := artifacts.Dir("testbeds", testbed.Name)
+		if err != nil {
+			logger.Warnln(err.Error())
+			continue
+		}
+		path := filepath.Join(dir, "topology.dot")
+		if err := os.WriteFile(path, []byte(graph.DOT(testbed.Name)), 0644); err != nil {
+			logger.WithField("extended", err.Error()).Warnln("failed to save testbed topology")
+			continue
+		}
+		testbed.AddArtifact(path)
+	}
+}
+
+func init() {
+	topologyCmd.Flags().StringVarP(&topologyFormat, "format", "f", "dot", "output format (dot, mermaid or json)")
+	topologyCmd.Flags().StringVar(&artifactsDir, "artifacts-dir", "./.neat/artifacts", "directory to store run artifacts in (within a directory per run)")
+	rootCmd.AddCommand(topologyCmd)
+}
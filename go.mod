@@ -7,12 +7,14 @@ require (
 	github.com/containerd/containerd v1.5.3 // indirect
 	github.com/docker/docker v20.10.7+incompatible
 	github.com/docker/go-connections v0.4.0 // indirect
+	github.com/docker/go-units v0.4.0
 	github.com/fatih/structs v1.1.0
 	github.com/go-resty/resty/v2 v2.6.0
-	github.com/mitchellh/mapstructure v1.4.1 // indirect
+	github.com/mitchellh/mapstructure v1.4.1
 	github.com/segmentio/ksuid v1.0.4
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.2.1
 	github.com/spf13/viper v1.8.1
 	google.golang.org/grpc v1.39.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
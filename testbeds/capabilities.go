@@ -0,0 +1,304 @@
+package testbeds
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/willfantom/neat/types"
+)
+
+//Capability names an operation that a testbed variant may support beyond its lifecycle
+type Capability string
+
+const (
+	CapabilityPing     Capability = "ping"
+	CapabilityExec     Capability = "exec"
+	CapabilityFault    Capability = "fault"
+	CapabilityLinks    Capability = "links"
+	CapabilityTopology Capability = "topology"
+	CapabilityCapture  Capability = "capture"
+	CapabilityVNF      Capability = "vnf"
+)
+
+var interfaceName = regexp.MustCompile(`^[A-Za-z0-9_.:@-]+$`)
+
+//Operations maps each capability a variant supports to its implementation,
+//the value must be the operation type that matches the capability
+type Operations map[Capability]interface{}
+
+func (operations Operations) validate() error {
+	for capability, operation := range operations {
+		ok := false
+		switch capability {
+		case CapabilityPing:
+			_, ok = operation.(PingOperation)
+		case CapabilityExec:
+			_, ok = operation.(ExecOperation)
+		case CapabilityLinks:
+			_, ok = operation.(LinksOperation)
+		case CapabilityFault:
+			_, ok = operation.(FaultOperation)
+		case CapabilityTopology:
+			_, ok = operation.(TopologyOperation)
+		case CapabilityCapture:
+			_, ok = operation.(CaptureOperation)
+		case CapabilityVNF:
+			_, ok = operation.(VNFOperation)
+		default:
+			return fmt.Errorf("capability '%s' is not known", capability)
+		}
+		if !ok {
+			return fmt.Errorf("operation for the '%s' capability has the wrong type %T", capability, operation)
+		}
+	}
+	return nil
+}
+
+type PingOperation func(ctx context.Context, testbed *Testbed, request types.PingRequest) (*types.PingResponse, error)
+
+//ExecOperation runs a command on the testbed, or on one of its nodes if the request names one
+type ExecOperation func(ctx context.Context, testbed *Testbed, request types.ExecRequest) (*types.ExecResponse, error)
+
+//LinksOperation lists the links between the testbed's nodes
+type LinksOperation func(testbed *Testbed) ([]types.Link, error)
+
+//FaultOperation changes the state or parameters of a link, giving the link as it was before the change
+type FaultOperation func(testbed *Testbed, request types.LinkRequest) (*types.Link, error)
+
+//TopologyOperation describes the nodes of the testbed's emulated network and the links between them
+type TopologyOperation func(testbed *Testbed) (*types.Topology, error)
+
+//CaptureOperation starts a packet capture, giving a function that stops it
+type CaptureOperation func(testbed *Testbed, request types.CaptureRequest) (StopCapture, error)
+
+//StopCapture stops a packet capture, saving its pcap files into the given directory
+//and giving their paths, if no directory is given the capture is discarded
+type StopCapture func(dir string) ([]string, error)
+
+//VNFOperation queries or controls the testbed's vnfs, giving the state of the requested vnfs afterwards
+type VNFOperation func(testbed *Testbed, request types.VNFRequest) ([]types.VNF, error)
+
+type UnsupportedCapabilityError struct {
+	Testbed    string
+	Variant    string
+	Capability Capability
+}
+
+func (e *UnsupportedCapabilityError) Error() string {
+	return fmt.Sprintf("testbed '%s' (variant '%s') does not support the '%s' capability", e.Testbed, e.Variant, e.Capability)
+}
+
+func (variant Variant) Supports(capability Capability) bool {
+	_, ok := variant.Operations[capability]
+	return ok
+}
+
+func (variant Variant) Capabilities() []Capability {
+	capabilities := make([]Capability, 0, len(variant.Operations))
+	for capability := range variant.Operations {
+		capabilities = append(capabilities, capability)
+	}
+	return capabilities
+}
+
+func (testbed *Testbed) Supports(capability Capability) bool {
+	return testbed.variant.Supports(capability)
+}
+
+//Require checks that the testbed's variant supports all of the given capabilities
+func (testbed *Testbed) Require(capabilities ...Capability) error {
+	for _, capability := range capabilities {
+		if !testbed.Supports(capability) {
+			return testbed.unsupported(capability)
+		}
+	}
+	return nil
+}
+
+func (testbed *Testbed) unsupported(capability Capability) error {
+	return &UnsupportedCapabilityError{
+		Testbed:    testbed.Name,
+		Variant:    testbed.VariantName,
+		Capability: capability,
+	}
+}
+
+func (testbed *Testbed) DoPing(ctx context.Context, request types.PingRequest) (*types.PingResponse, error) {
+	operation, ok := testbed.variant.Operations[CapabilityPing].(PingOperation)
+	if !ok {
+		return nil, testbed.unsupported(CapabilityPing)
+	}
+	return operation(ctx, testbed, request)
+}
+
+func (testbed *Testbed) Exec(ctx context.Context, request types.ExecRequest) (*types.ExecResponse, error) {
+	operation, ok := testbed.variant.Operations[CapabilityExec].(ExecOperation)
+	if !ok {
+		return nil, testbed.unsupported(CapabilityExec)
+	}
+	return operation(ctx, testbed, request)
+}
+
+func (testbed *Testbed) Links() ([]types.Link, error) {
+	operation, ok := testbed.variant.Operations[CapabilityLinks].(LinksOperation)
+	if !ok {
+		return nil, testbed.unsupported(CapabilityLinks)
+	}
+	return operation(testbed)
+}
+
+func (testbed *Testbed) Topology() (*types.Topology, error) {
+	operation, ok := testbed.variant.Operations[CapabilityTopology].(TopologyOperation)
+	if !ok {
+		return nil, testbed.unsupported(CapabilityTopology)
+	}
+	return operation(testbed)
+}
+
+//StartCapture starts a packet capture on the testbed, the returned function must be called to stop it
+func (testbed *Testbed) StartCapture(request types.CaptureRequest) (StopCapture, error) {
+	operation, ok := testbed.variant.Operations[CapabilityCapture].(CaptureOperation)
+	if !ok {
+		return nil, testbed.unsupported(CapabilityCapture)
+	}
+	if err := ValidateCaptureRequest(request); err != nil {
+		return nil, err
+	}
+	return operation(testbed, request)
+}
+
+func (testbed *Testbed) VNFs() ([]types.VNF, error) {
+	return testbed.doVNF(types.VNFRequest{})
+}
+
+//ControlVNF performs the action on the named vnf, an empty action only queries its state
+func (testbed *Testbed) ControlVNF(name string, action string) (*types.VNF, error) {
+	if name == "" {
+		return nil, fmt.Errorf("a vnf name must be given")
+	}
+	switch action {
+	case "", types.VNFActionStart, types.VNFActionStop, types.VNFActionReboot:
+	default:
+		return nil, fmt.Errorf("vnf action '%s' is not valid (start, stop or reboot)", action)
+	}
+	vnfs, err := testbed.doVNF(types.VNFRequest{Name: name, Action: action})
+	if err != nil {
+		return nil, err
+	}
+	for idx := range vnfs {
+		if vnfs[idx].Name == name {
+			return &vnfs[idx], nil
+		}
+	}
+	return nil, fmt.Errorf("vnf '%s' does not exist on testbed '%s'", name, testbed.Name)
+}
+
+//WaitForVNF polls the named vnf until it has booted, giving up after the timeout or if the context is cancelled,
+//after a reboot the vnf must either be seen to go down or have a boot event recorded on the testbed's timeline
+//so that it is not taken as booted while it is still up from before the reboot
+func (testbed *Testbed) WaitForVNF(ctx context.Context, name string, rebooted bool, timeout time.Duration, interval time.Duration) (*types.VNF, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("vnf wait interval must be positive")
+	}
+	seenEvents := len(testbed.Events())
+	wentDown := !rebooted
+	deadline := time.After(timeout)
+	for {
+		vnf, err := testbed.ControlVNF(name, "")
+		if err != nil {
+			return nil, err
+		}
+		if !vnf.Booted {
+			wentDown = true
+		} else if wentDown || testbed.vnfBootedSince(name, seenEvents) {
+			return vnf, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for vnf '%s' to boot: %w", name, ctx.Err())
+		case <-deadline:
+			return vnf, fmt.Errorf("vnf '%s' did not boot within %s (state: %s)", name, timeout, vnf.State)
+		case <-time.After(interval):
+		}
+	}
+}
+
+//vnfBootedSince reports if a boot event for the vnf is on the testbed's timeline after the first count events
+func (testbed *Testbed) vnfBootedSince(name string, count int) bool {
+	for _, event := range testbed.Events()[count:] {
+		if event.Type == types.EventVNFBooted && event.Subject == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (testbed *Testbed) doVNF(request types.VNFRequest) ([]types.VNF, error) {
+	operation, ok := testbed.variant.Operations[CapabilityVNF].(VNFOperation)
+	if !ok {
+		return nil, testbed.unsupported(CapabilityVNF)
+	}
+	return operation(testbed, request)
+}
+
+//SetLink changes the state or parameters of a link, the returned link can be used to restore it
+func (testbed *Testbed) SetLink(request types.LinkRequest) (*types.Link, error) {
+	operation, ok := testbed.variant.Operations[CapabilityFault].(FaultOperation)
+	if !ok {
+		return nil, testbed.unsupported(CapabilityFault)
+	}
+	if err := ValidateLinkRequest(request); err != nil {
+		return nil, err
+	}
+	return operation(testbed, request)
+}
+
+//ValidateLinkRequest checks that a link request names a link and only gives valid changes
+func ValidateLinkRequest(request types.LinkRequest) error {
+	if request.Node1 == "" || request.Node2 == "" {
+		return fmt.Errorf("a link must be given by both of its nodes")
+	}
+	switch request.State {
+	case "", types.LinkStateUp, types.LinkStateDown:
+	default:
+		return fmt.Errorf("link state '%s' is not valid (up or down)", request.State)
+	}
+	if request.State == "" && !request.HasParams() {
+		return fmt.Errorf("no change given for link %s-%s", request.Node1, request.Node2)
+	}
+	if request.Bandwidth != nil && *request.Bandwidth < 0 {
+		return fmt.Errorf("link bandwidth can not be negative")
+	}
+	if request.Loss != nil && (*request.Loss < 0 || *request.Loss > 100) {
+		return fmt.Errorf("link loss must be a percentage between 0 and 100")
+	}
+	for name, value := range map[string]*string{"delay": request.Delay, "jitter": request.Jitter} {
+		if value == nil || *value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(*value); err != nil {
+			return fmt.Errorf("link %s '%s' is not a valid duration", name, *value)
+		}
+	}
+	return nil
+}
+
+//ValidateCaptureRequest checks that a capture request names at least one interface or link
+func ValidateCaptureRequest(request types.CaptureRequest) error {
+	if len(request.Interfaces) == 0 && len(request.Links) == 0 {
+		return fmt.Errorf("a capture needs at least one interface or link")
+	}
+	for _, name := range request.Interfaces {
+		if !interfaceName.MatchString(name) {
+			return fmt.Errorf("interface name '%s' is not valid", name)
+		}
+	}
+	for _, link := range request.Links {
+		if link.Node1 == "" || link.Node2 == "" {
+			return fmt.Errorf("a link must be given by both of its nodes")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package testbeds
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/willfantom/neat/types"
+)
+
+//vnfTestbed gives a testbed whose vnf reports each of the given boot states in turn, staying in the last
+func vnfTestbed(states ...bool) (*Testbed, func() int) {
+	lock := sync.Mutex{}
+	polls := 0
+	testbed := &Testbed{Name: "vnf-test"}
+	testbed.variant = Variant{
+		Name: "vnf-test",
+		Operations: Operations{
+			CapabilityVNF: VNFOperation(func(testbed *Testbed, request types.VNFRequest) ([]types.VNF, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				booted := states[len(states)-1]
+				if polls < len(states) {
+					booted = states[polls]
+				}
+				polls++
+				return []types.VNF{{Name: "fw", State: "running", Booted: booted}}, nil
+			}),
+		},
+	}
+	return testbed, func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return polls
+	}
+}
+
+func TestWaitForVNFAfterReboot(t *testing.T) {
+	testbed, polls := vnfTestbed(true, true, false, true)
+	vnf, err := testbed.WaitForVNF(context.Background(), "fw", true, time.Second, time.Millisecond)
+	if err != nil || !vnf.Booted {
+		t.Fatalf("vnf did not boot: %v", err)
+	}
+	if polls() != 4 {
+		t.Errorf("vnf was taken as booted before it went down, after %d polls", polls())
+	}
+}
+
+func TestWaitForVNFBootEvent(t *testing.T) {
+	testbed, polls := vnfTestbed(true)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		testbed.RecordEvent(types.Event{Time: time.Now(), Type: types.EventVNFBooted, Subject: "fw"})
+	}()
+	vnf, err := testbed.WaitForVNF(context.Background(), "fw", true, time.Second, 5*time.Millisecond)
+	if err != nil || !vnf.Booted {
+		t.Fatalf("vnf did not boot: %v", err)
+	}
+	if polls() < 2 {
+		t.Errorf("vnf was taken as booted before its boot event")
+	}
+}
+
+func TestWaitForVNFWithoutReboot(t *testing.T) {
+	testbed, polls := vnfTestbed(false, true)
+	if _, err := testbed.WaitForVNF(context.Background(), "fw", false, time.Second, time.Millisecond); err != nil {
+		t.Fatalf("vnf did not boot: %v", err)
+	}
+	if polls() != 2 {
+		t.Errorf("expected 2 polls, got %d", polls())
+	}
+}
+
+func TestWaitForVNFStops(t *testing.T) {
+	testbed, _ := vnfTestbed(false)
+	vnf, err := testbed.WaitForVNF(context.Background(), "fw", false, 20*time.Millisecond, time.Millisecond)
+	if err == nil || vnf == nil || vnf.Booted {
+		t.Errorf("expected a timeout with the vnf's last state, got %+v %v", vnf, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	if _, err := testbed.WaitForVNF(ctx, "fw", false, time.Minute, 10*time.Millisecond); err == nil {
+		t.Errorf("expected an error once cancelled")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("cancelling did not stop the wait")
+	}
+
+	if _, err := testbed.WaitForVNF(context.Background(), "fw", false, time.Second, 0); err == nil {
+		t.Errorf("expected a zero interval to be rejected")
+	}
+}
+
+func TestRegisterChecksOperationTypes(t *testing.T) {
+	links := LinksOperation(func(testbed *Testbed) ([]types.Link, error) {
+		return nil, nil
+	})
+	if err := Register("wrong-type-test", Variant{Operations: Operations{CapabilityTopology: links}}); err == nil {
+		t.Errorf("expected an operation of the wrong type to be rejected")
+	}
+	if err := Register("unknown-test", Variant{Operations: Operations{Capability("teleport"): links}}); err == nil {
+		t.Errorf("expected an unknown capability to be rejected")
+	}
+	if VariantExists("wrong-type-test") || VariantExists("unknown-test") {
+		t.Errorf("invalid variants should not be registered")
+	}
+
+	if err := Register("links-test", Variant{Operations: Operations{CapabilityLinks: links}}); err != nil {
+		t.Fatalf("failed to register variant: %v", err)
+	}
+	defer delete(Variants, "links-test")
+	if err := Register("links-test", Variant{}); err == nil {
+		t.Errorf("expected a duplicate variant to be rejected")
+	}
+}
@@ -9,3 +9,10 @@ func generateID() string {
 	}
 	return id.String()
 }
+
+var runID = generateID()
+
+//RunID identifies this execution of neat
+func RunID() string {
+	return runID
+}
@@ -1,24 +1,34 @@
 package testbeds
 
-import "time"
+import (
+	"time"
+
+	"github.com/willfantom/neat/types"
+)
 
 type Testbed struct {
-	Name string `mapstructure:"name" json:"name"`
+	Name    string `mapstructure:"name" json:"name"`
+	Extends string `mapstructure:"extends" json:"extends,omitempty"`
 
 	VariantName string `mapstructure:"variant" json:"variant"`
 	variant     Variant
+	failed      bool
+	dir         string
+	created     bool
+	started     bool
 
-	ResourceCap bool `mapstructure:"resource_cap" json:"resource_cap"`
+	ResourceCap    *ResourceCap  `mapstructure:"resource_cap" json:"resource_cap,omitempty"`
+	SampleInterval time.Duration `mapstructure:"sample_interval" json:"sample_interval,omitempty"`
 
-	PreStartScript  string `mapstructure:"pre_start_script" json:"pre_start_script"`
-	PreStart        string `mapstructure:"pre_start" json:"pre_start"`
-	PostStartScript string `mapstructure:"post_start_script" json:"post_start_script"`
-	PostStart       string `mapstructure:"post_start" json:"post_start"`
+	PreStartScript  string `mapstructure:"pre_start_script" json:"pre_start_script,omitempty"`
+	PreStart        string `mapstructure:"pre_start" json:"pre_start,omitempty"`
+	PostStartScript string `mapstructure:"post_start_script" json:"post_start_script,omitempty"`
+	PostStart       string `mapstructure:"post_start" json:"post_start,omitempty"`
 
-	PreStopScript  string `mapstructure:"pre_stop_script" json:"pre_stop_script"`
-	PreStop        string `mapstructure:"pre_stop" json:"pre_stop"`
-	PostStopScript string `mapstructure:"post_stop_script" json:"post_stop_script"`
-	PostStop       string `mapstructure:"post_stop" json:"post_stop"`
+	PreStopScript  string `mapstructure:"pre_stop_script" json:"pre_stop_script,omitempty"`
+	PreStop        string `mapstructure:"pre_stop" json:"pre_stop,omitempty"`
+	PostStopScript string `mapstructure:"post_stop_script" json:"post_stop_script,omitempty"`
+	PostStop       string `mapstructure:"post_stop" json:"post_stop,omitempty"`
 
 	VariantConfig map[string]interface{} `mapstructure:"config" json:"config"`
 
@@ -30,7 +40,10 @@ type Metrics struct {
 	CreationTime time.Duration `mapstructure:"creation_time" json:"creation_time"`
 	RemovedAt    time.Time     `mapstructure:"removed_at" json:"removed_at"`
 	RemoveTime   time.Duration `mapstructure:"remove_time" json:"remove_time"`
+	ImageDigest  string        `mapstructure:"image_digest" json:"image_digest,omitempty"`
 	Runs         []RunMetrics  `mapstructure:"runs" json:"runs"`
+	Artifacts    []string      `mapstructure:"artifacts" json:"artifacts,omitempty"`
+	Events       []types.Event `mapstructure:"events" json:"events,omitempty"`
 }
 
 type RunMetrics struct {
@@ -40,4 +53,13 @@ type RunMetrics struct {
 	StopTime        time.Duration `mapstructure:"stop_time" json:"stop_time"`
 	CPUUsage        float64       `mapstructure:"cpu_usage" json:"cpu_usage"`
 	PeakMemoryUsage float64       `mapstructure:"peak_memory_usage" json:"peak_memory_usage"`
+
+	Samples []ResourceSample `mapstructure:"samples" json:"samples,omitempty"`
+}
+
+//ResourceSample is a point in a testbed's resource usage time series, usage is given as a percentage
+type ResourceSample struct {
+	Time        time.Time `mapstructure:"time" json:"time"`
+	CPUUsage    float64   `mapstructure:"cpu_usage" json:"cpu_usage"`
+	MemoryUsage float64   `mapstructure:"memory_usage" json:"memory_usage"`
 }
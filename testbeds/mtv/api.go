@@ -0,0 +1,102 @@
+package mtv
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/testbeds/mtv/mnapi"
+)
+
+func validateAPIConfig(testbed *testbeds.Testbed, api APIConfig) error {
+	if api.URL != "" {
+		target, err := url.Parse(api.URL)
+		if err != nil || !target.IsAbs() {
+			return fmt.Errorf("api url '%s' must be an absolute url", api.URL)
+		}
+	}
+	if api.Scheme != "http" && api.Scheme != "https" {
+		return fmt.Errorf("api scheme '%s' is not valid (http or https)", api.Scheme)
+	}
+	if api.Port < 1 || api.Port > 65535 {
+		return fmt.Errorf("api port %d is not valid", api.Port)
+	}
+	if api.ReadyTimeout < 0 {
+		return fmt.Errorf("api ready timeout can not be negative")
+	}
+	if api.Token != "" && api.TokenFile != "" {
+		return fmt.Errorf("only one of api token and token_file can be given")
+	}
+	if (api.CertFile == "") != (api.KeyFile == "") {
+		return fmt.Errorf("an api client certificate needs both a cert_file and a key_file")
+	}
+	files := map[string]string{
+		"api token_file": api.TokenFile,
+		"api ca_file":    api.CAFile,
+		"api cert_file":  api.CertFile,
+		"api key_file":   api.KeyFile,
+	}
+	for field, path := range files {
+		if path == "" {
+			continue
+		}
+		if err := testbeds.CheckPath(field, testbed.ResolvePath(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//apiClient gives a client for the testbed's mtv api, by default requests that fail temporarily are retried,
+//the given options are applied after the defaults and those from the testbed's api config, the testbed's
+//container is only needed when no api url is given
+func apiClient(testbed *testbeds.Testbed, options ...mnapi.Option) (*mnapi.Client, error) {
+	parsedConfig, err := parseConfig(testbed.VariantConfig)
+	if err != nil {
+		return nil, err
+	}
+	api := parsedConfig.API
+	target := api.URL
+	if target == "" {
+		container, ok := testbedContainer(testbed)
+		if !ok {
+			return nil, fmt.Errorf("mtv testbed has no container")
+		}
+		ip, err := container.GetIP()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get container ip")
+		}
+		target = fmt.Sprintf("%s://%s:%d", api.Scheme, ip, api.Port)
+	}
+	clientOptions := []mnapi.Option{
+		mnapi.WithRetries(3, 500*time.Millisecond, 5*time.Second),
+		mnapi.WithTLS(mnapi.TLSConfig{
+			CAFile:             resolveOptional(testbed, api.CAFile),
+			CertFile:           resolveOptional(testbed, api.CertFile),
+			KeyFile:            resolveOptional(testbed, api.KeyFile),
+			InsecureSkipVerify: api.InsecureSkipVerify,
+		}),
+	}
+	token := api.Token
+	if api.TokenFile != "" {
+		contents, err := os.ReadFile(testbed.ResolvePath(api.TokenFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read api token file: %w", err)
+		}
+		token = strings.TrimSpace(string(contents))
+	}
+	if token != "" {
+		clientOptions = append(clientOptions, mnapi.WithBearerToken(token))
+	}
+	return mnapi.NewClient(target, api.Headers, append(clientOptions, options...)...)
+}
+
+func resolveOptional(testbed *testbeds.Testbed, path string) string {
+	if path == "" {
+		return ""
+	}
+	return testbed.ResolvePath(path)
+}
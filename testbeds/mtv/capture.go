@@ -0,0 +1,158 @@
+package mtv
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/tools/docker"
+	"github.com/willfantom/neat/types"
+)
+
+const captureDir string = "/tmp/neat-capture"
+
+type capture struct {
+	interfaceName string
+	pid           string
+	file          string
+}
+
+//startCapture runs tcpdump in the container for every requested interface, links are captured on
+//whichever of their interfaces is in the container's network namespace (normally the switch side)
+func startCapture(testbed *testbeds.Testbed, request types.CaptureRequest) (testbeds.StopCapture, error) {
+	container, ok := testbedContainer(testbed)
+	if !ok {
+		return nil, fmt.Errorf("mtv testbed has no container")
+	}
+	if _, err := run(container, "command -v tcpdump"); err != nil {
+		return nil, fmt.Errorf("tcpdump is not available in the mtv container: %w", err)
+	}
+	//interfaces of emulated hosts are in their own network namespaces which tcpdump in the container can not see
+	for _, interfaceName := range request.Interfaces {
+		if !interfaceExists(container, interfaceName) {
+			return nil, fmt.Errorf("interface %s is not in the mtv container's network namespace, capture the link it is on instead", interfaceName)
+		}
+	}
+	interfaces := append([]string{}, request.Interfaces...)
+	if len(request.Links) > 0 {
+		linkInterfaces, err := captureInterfaces(testbed, container, request.Links)
+		if err != nil {
+			return nil, err
+		}
+		interfaces = append(interfaces, linkInterfaces...)
+	}
+	dir := path.Join(captureDir, strconv.FormatInt(time.Now().UnixNano(), 10))
+	if _, err := run(container, "mkdir -p "+dir); err != nil {
+		return nil, err
+	}
+	captures := make([]capture, 0, len(interfaces))
+	stop := func(hostDir string) ([]string, error) {
+		return stopCapture(container, dir, captures, hostDir)
+	}
+	filter := ""
+	if request.Filter != "" {
+		filter = " " + shellQuote(request.Filter)
+	}
+	for _, interfaceName := range interfaces {
+		file := path.Join(dir, interfaceName+".pcap")
+		command := fmt.Sprintf("tcpdump -i %s -U -w %s%s >/dev/null 2>&1 & echo $!", shellQuote(interfaceName), shellQuote(file), filter)
+		output, err := run(container, command)
+		if err != nil {
+			stop("")
+			return nil, fmt.Errorf("failed to start capture on %s: %w", interfaceName, err)
+		}
+		captures = append(captures, capture{
+			interfaceName: interfaceName,
+			pid:           strings.TrimSpace(output),
+			file:          file,
+		})
+	}
+	return stop, nil
+}
+
+func stopCapture(container *docker.NeatContainer, dir string, captures []capture, hostDir string) ([]string, error) {
+	saved := make([]string, 0, len(captures))
+	for _, capture := range captures {
+		//tcpdump flushes and closes the file on interrupt, wait for it to exit before copying
+		command := fmt.Sprintf("kill -INT %s; while kill -0 %s 2>/dev/null; do sleep 0.1; done", capture.pid, capture.pid)
+		if _, err := run(container, command); err != nil {
+			logrus.WithField("interface", capture.interfaceName).Warnln("failed to stop capture: " + err.Error())
+		}
+	}
+	var copyErr error
+	if hostDir != "" {
+		for _, capture := range captures {
+			if err := container.CopyFrom(capture.file, hostDir); err != nil {
+				copyErr = fmt.Errorf("failed to save capture of %s: %w", capture.interfaceName, err)
+				continue
+			}
+			saved = append(saved, filepath.Join(hostDir, path.Base(capture.file)))
+		}
+	}
+	if _, err := run(container, "rm -rf "+dir); err != nil {
+		logrus.WithField("dir", dir).Warnln("failed to remove capture files: " + err.Error())
+	}
+	return saved, copyErr
+}
+
+func captureInterfaces(testbed *testbeds.Testbed, container *docker.NeatContainer, refs []types.LinkRef) ([]string, error) {
+	links, err := doLinks(testbed)
+	if err != nil {
+		return nil, err
+	}
+	interfaces := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		var found *types.Link
+		for idx, link := range links {
+			if (link.Node1 == ref.Node1 && link.Node2 == ref.Node2) || (link.Node1 == ref.Node2 && link.Node2 == ref.Node1) {
+				found = &links[idx]
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("no link between %s and %s", ref.Node1, ref.Node2)
+		}
+		interfaceName := ""
+		for _, candidate := range []string{found.Interface1, found.Interface2} {
+			if candidate == "" {
+				continue
+			}
+			if interfaceExists(container, candidate) {
+				interfaceName = candidate
+				break
+			}
+		}
+		if interfaceName == "" {
+			return nil, fmt.Errorf("link between %s and %s has no interface that can be captured", ref.Node1, ref.Node2)
+		}
+		interfaces = append(interfaces, interfaceName)
+	}
+	return interfaces, nil
+}
+
+func interfaceExists(container *docker.NeatContainer, interfaceName string) bool {
+	_, err := run(container, "test -e /sys/class/net/"+shellQuote(interfaceName))
+	return err == nil
+}
+
+//run executes the shell command in the container, giving its output and an error if it exits non-zero
+func run(container *docker.NeatContainer, command string) (string, error) {
+	result, err := container.Exec(context.Background(), []string{"sh", "-c", command}, docker.ExecOptions{Timeout: 30 * time.Second})
+	if err != nil {
+		return "", err
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("'%s' exited with %d: %s", command, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	return result.Stdout, nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,146 @@
+package mtv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/tools/docker"
+)
+
+const (
+	defaultControllerPort           int           = 6653
+	defaultControllerConnectTimeout time.Duration = 2 * time.Minute
+)
+
+//ControllerConfig gives the sdn controller the testbed's switches connect to, either a container started
+//alongside the testbed on its network or the address of a controller that is already running,
+//the topology is given its address through the CONTROLLER_IP and CONTROLLER_PORT environment variables
+type ControllerConfig struct {
+	Image          string            `mapstructure:"image"`
+	PullPolicy     string            `mapstructure:"pull_policy"`
+	Environment    map[string]string `mapstructure:"environment"`
+	Command        []string          `mapstructure:"command"`
+	Address        string            `mapstructure:"address"`
+	Port           int               `mapstructure:"port"`
+	ConnectTimeout time.Duration     `mapstructure:"connect_timeout"`
+}
+
+var (
+	controllersLock sync.Mutex
+	controllers     = make(map[string]*docker.NeatContainer)
+)
+
+func validateControllerConfig(controller *ControllerConfig) error {
+	if (controller.Image == "") == (controller.Address == "") {
+		return fmt.Errorf("a controller needs exactly one of an image or an address")
+	}
+	if controller.Port < 1 || controller.Port > 65535 {
+		return fmt.Errorf("controller port %d is not valid", controller.Port)
+	}
+	if controller.PullPolicy != "" && !docker.PullPolicy(controller.PullPolicy).Valid() {
+		return fmt.Errorf("controller pull policy '%s' is not valid (always, if-not-present or never)", controller.PullPolicy)
+	}
+	if controller.ConnectTimeout < 0 {
+		return fmt.Errorf("controller connect timeout can not be negative")
+	}
+	return nil
+}
+
+//startController starts the testbed's controller container if it has one, giving the address
+//the topology should connect its switches to
+func startController(ctx context.Context, testbed *testbeds.Testbed, engine *docker.Engine, controller *ControllerConfig, network string) (string, error) {
+	if controller.Image == "" {
+		return controller.Address, nil
+	}
+	container := docker.NeatContainer{
+		Engine:      engine,
+		Name:        testbed.Name + "-controller",
+		Image:       controller.Image,
+		Network:     network,
+		Environment: controller.Environment,
+		Command:     controller.Command,
+		Labels: map[string]string{
+			"name":    testbed.Name,
+			"variant": testbed.VariantName,
+			"run":     testbeds.RunID(),
+			"role":    "controller",
+		},
+	}
+	if err := container.EnsureImage(ctx, docker.PullPolicy(controller.PullPolicy)); err != nil {
+		return "", err
+	}
+	if err := container.Create(ctx); err != nil {
+		return "", fmt.Errorf("failed to create controller: %w", err)
+	}
+	controllersLock.Lock()
+	controllers[testbed.Name] = &container
+	controllersLock.Unlock()
+	if err := container.Start(ctx); err != nil {
+		return "", fmt.Errorf("failed to start controller: %w", err)
+	}
+	ip, err := container.GetIP()
+	if err != nil {
+		return "", fmt.Errorf("failed to get controller ip: %w", err)
+	}
+	return ip, nil
+}
+
+//removeController stops and removes the testbed's controller container if one was created
+func removeController(ctx context.Context, testbed *testbeds.Testbed) error {
+	controllersLock.Lock()
+	container, ok := controllers[testbed.Name]
+	controllersLock.Unlock()
+	if !ok {
+		return nil
+	}
+	//the controller is removed even if it could not be stopped cleanly
+	stopErr := container.Stop(ctx)
+	if err := container.Remove(ctx); err != nil {
+		return fmt.Errorf("failed to remove controller: %w", err)
+	}
+	controllersLock.Lock()
+	delete(controllers, testbed.Name)
+	controllersLock.Unlock()
+	if stopErr != nil {
+		return fmt.Errorf("failed to stop controller: %w", stopErr)
+	}
+	return nil
+}
+
+//waitForSwitches polls open vswitch in the testbed's container until every switch's controller
+//connection is up
+func waitForSwitches(ctx context.Context, testbed *testbeds.Testbed, container *docker.NeatContainer, timeout time.Duration) error {
+	logger := logrus.WithField("testbed", testbed.Name)
+	deadline := time.After(timeout)
+	for {
+		output, err := run(container, "ovs-vsctl --format=csv --no-headings --columns=is_connected list controller")
+		if err == nil {
+			states := strings.Fields(output)
+			connected := 0
+			for _, state := range states {
+				if state == "true" {
+					connected++
+				}
+			}
+			if len(states) > 0 && connected == len(states) {
+				logger.Debugln(strconv.Itoa(connected) + " switch controller connections are up")
+				return nil
+			}
+		} else {
+			logger.Debugln(err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("switches did not connect to the controller: %w", ctx.Err())
+		case <-deadline:
+			return fmt.Errorf("switches did not connect to the controller within %s", timeout)
+		case <-time.After(time.Second):
+		}
+	}
+}
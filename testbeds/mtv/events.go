@@ -0,0 +1,179 @@
+package mtv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/testbeds/mtv/mnapi"
+	"github.com/willfantom/neat/types"
+)
+
+//eventStream is a testbed's subscription to the mtv api's events, closed once no more events will be recorded
+type eventStream struct {
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+var (
+	eventStreamsLock sync.Mutex
+	eventStreams     = make(map[string]*eventStream)
+)
+
+//waitForReady waits for the mtv api to report that the topology has started using its event stream,
+//which is then recorded on the testbed's timeline until the testbed is removed, if the api has no event
+//stream the first successful response from it is taken as ready
+func waitForReady(ctx context.Context, testbed *testbeds.Testbed, client *mnapi.Client) error {
+	parsedConfig, err := parseConfig(testbed.VariantConfig)
+	if err != nil {
+		return err
+	}
+	wait := &readyWait{
+		ctx:      ctx,
+		logger:   logrus.WithField("testbed", testbed.Name),
+		timeout:  parsedConfig.API.ReadyTimeout,
+		deadline: time.After(parsedConfig.API.ReadyTimeout),
+	}
+	stopEvents(testbed)
+	for {
+		streamCtx, cancel := context.WithCancel(context.Background())
+		events, err := client.Events(streamCtx)
+		if err == nil {
+			stream := &eventStream{
+				cancel: cancel,
+				closed: make(chan struct{}),
+			}
+			eventStreamsLock.Lock()
+			eventStreams[testbed.Name] = stream
+			eventStreamsLock.Unlock()
+			return recordEvents(wait, testbed, events, stream.closed)
+		}
+		cancel()
+		var requestErr *mnapi.RequestError
+		if errors.As(err, &requestErr) && requestErr.Status == http.StatusNotFound {
+			return pollReady(wait, client)
+		}
+		if err := wait.retry(err); err != nil {
+			return err
+		}
+	}
+}
+
+//readyWait bounds how long neat waits for the mtv api to start, keeping the last error to report if it never does
+type readyWait struct {
+	ctx      context.Context
+	logger   *logrus.Entry
+	timeout  time.Duration
+	deadline <-chan time.Time
+	lastErr  error
+}
+
+//retry waits before the api is tried again after the given error, giving an error instead if the error
+//is not temporary or the wait has been cancelled or timed out
+func (wait *readyWait) retry(err error) error {
+	if !mnapi.IsTemporary(err) {
+		return fmt.Errorf("mtv api failed to start: %w", err)
+	}
+	wait.lastErr = err
+	wait.logger.Debugln("waiting for mtv api: " + err.Error())
+	select {
+	case <-wait.ctx.Done():
+		return fmt.Errorf("mtv api did not start: %w", wait.ctx.Err())
+	case <-wait.deadline:
+		return fmt.Errorf("mtv api did not start within %s: %w", wait.timeout, wait.lastErr)
+	case <-time.After(500 * time.Millisecond):
+		return nil
+	}
+}
+
+//recordEvents adds every event from the stream to the testbed's timeline, returning once the topology is ready,
+//closed is closed once the stream has ended and every event has been recorded
+func recordEvents(wait *readyWait, testbed *testbeds.Testbed, events <-chan mnapi.Event, closed chan struct{}) error {
+	ready := make(chan struct{})
+	go func() {
+		defer close(closed)
+		isReady := false
+		for event := range events {
+			testbed.RecordEvent(convertEvent(event))
+			if !isReady && event.Type == mnapi.EventReady {
+				isReady = true
+				close(ready)
+			}
+		}
+	}()
+	select {
+	case <-ready:
+		return nil
+	case <-closed:
+		return fmt.Errorf("mtv event stream ended before the topology was ready")
+	case <-wait.ctx.Done():
+		stopEvents(testbed)
+		return fmt.Errorf("mtv topology did not become ready: %w", wait.ctx.Err())
+	case <-wait.deadline:
+		stopEvents(testbed)
+		return fmt.Errorf("mtv topology did not become ready within %s", wait.timeout)
+	}
+}
+
+func pollReady(wait *readyWait, client *mnapi.Client) error {
+	for {
+		_, err := client.GetNodes()
+		if err == nil {
+			return nil
+		}
+		if err := wait.retry(err); err != nil {
+			return err
+		}
+	}
+}
+
+//stopEvents stops recording the testbed's event stream if it is being recorded, waiting for the events
+//already received to be recorded so that the timeline is final
+func stopEvents(testbed *testbeds.Testbed) {
+	eventStreamsLock.Lock()
+	stream, ok := eventStreams[testbed.Name]
+	delete(eventStreams, testbed.Name)
+	eventStreamsLock.Unlock()
+	if ok {
+		stream.cancel()
+		<-stream.closed
+	}
+}
+
+func convertEvent(event mnapi.Event) types.Event {
+	converted := types.Event{
+		Time: event.Time,
+		Type: event.Type,
+	}
+	switch {
+	case event.Node != "":
+		converted.Subject = event.Node
+	case event.Node1 != "" || event.Node2 != "":
+		converted.Subject = event.Node1 + "-" + event.Node2
+	case event.VNF != "":
+		converted.Subject = event.VNF
+	}
+	if event.Params != nil {
+		details := make([]string, 0)
+		if event.Params.Bandwidth != 0 {
+			details = append(details, fmt.Sprintf("bw=%g", event.Params.Bandwidth))
+		}
+		if event.Params.Delay != "" {
+			details = append(details, "delay="+event.Params.Delay)
+		}
+		if event.Params.Jitter != "" {
+			details = append(details, "jitter="+event.Params.Jitter)
+		}
+		if event.Params.Loss != 0 {
+			details = append(details, fmt.Sprintf("loss=%g", event.Params.Loss))
+		}
+		converted.Detail = strings.Join(details, " ")
+	}
+	return converted
+}
@@ -0,0 +1,109 @@
+package mtv
+
+import (
+	"fmt"
+
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/testbeds/mtv/mnapi"
+	"github.com/willfantom/neat/types"
+)
+
+func doLinks(testbed *testbeds.Testbed) ([]types.Link, error) {
+	client, err := apiClient(testbed)
+	if err != nil {
+		return nil, err
+	}
+	linkInfo, err := client.GetLinks()
+	if err != nil {
+		return nil, err
+	}
+	links := make([]types.Link, 0, len(linkInfo))
+	for _, info := range linkInfo {
+		links = append(links, types.Link{
+			Node1:      info.Node1,
+			Node2:      info.Node2,
+			Interface1: info.Interface1,
+			Interface2: info.Interface2,
+			Up:         info.Status == mnapi.LinkUp,
+			Params: types.LinkParams{
+				Bandwidth: info.Params.Bandwidth,
+				Delay:     info.Params.Delay,
+				Jitter:    info.Params.Jitter,
+				Loss:      info.Params.Loss,
+			},
+		})
+	}
+	return links, nil
+}
+
+//doSetLink changes the link, parameters that are not in the request keep their current values
+//as the mtv api replaces all of a link's parameters at once
+func doSetLink(testbed *testbeds.Testbed, request types.LinkRequest) (*types.Link, error) {
+	links, err := doLinks(testbed)
+	if err != nil {
+		return nil, err
+	}
+	var previous *types.Link
+	for idx, link := range links {
+		if (link.Node1 == request.Node1 && link.Node2 == request.Node2) || (link.Node1 == request.Node2 && link.Node2 == request.Node1) {
+			previous = &links[idx]
+			break
+		}
+	}
+	if previous == nil {
+		return nil, fmt.Errorf("no link between %s and %s", request.Node1, request.Node2)
+	}
+	client, err := apiClient(testbed)
+	if err != nil {
+		return nil, err
+	}
+	if request.HasParams() {
+		params := previous.Params.Apply(request)
+		if err := client.SetLinkParams(previous.Node1, previous.Node2, mnapi.LinkParams{
+			Bandwidth: params.Bandwidth,
+			Delay:     params.Delay,
+			Jitter:    params.Jitter,
+			Loss:      params.Loss,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if request.State != "" {
+		if err := client.SetLinkStatus(previous.Node1, previous.Node2, request.State == types.LinkStateUp); err != nil {
+			return nil, err
+		}
+	}
+	return previous, nil
+}
+
+func doTopology(testbed *testbeds.Testbed) (*types.Topology, error) {
+	client, err := apiClient(testbed)
+	if err != nil {
+		return nil, err
+	}
+	classes, err := client.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	topology := types.Topology{
+		Nodes: make([]types.Node, 0),
+	}
+	for class := range classes {
+		nodes, err := client.GetNodesOfClass(class)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			topology.Nodes = append(topology.Nodes, types.Node{
+				Name:  node.Name,
+				Class: node.Class,
+				IPs:   node.IPs,
+				MACs:  node.MACs,
+			})
+		}
+	}
+	if topology.Links, err = doLinks(testbed); err != nil {
+		return nil, err
+	}
+	return &topology, nil
+}
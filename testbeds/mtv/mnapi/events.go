@@ -0,0 +1,104 @@
+package mnapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	EventReady       string = "ready"
+	EventNodeUp      string = "node_up"
+	EventNodeDown    string = "node_down"
+	EventLinkUp      string = "link_up"
+	EventLinkDown    string = "link_down"
+	EventLinkChanged string = "link_changed"
+	EventVNFBooted   string = "vnf_booted"
+	EventVNFStopped  string = "vnf_stopped"
+)
+
+//Event is a change in the emulator, only the fields relevant to the event's type are set,
+//a ready event is sent when the topology has started or straight away if it already has
+type Event struct {
+	Type   string      `json:"type"`
+	Time   time.Time   `json:"time"`
+	Node   string      `json:"node,omitempty"`
+	Node1  string      `json:"node1,omitempty"`
+	Node2  string      `json:"node2,omitempty"`
+	VNF    string      `json:"vnf,omitempty"`
+	Params *LinkParams `json:"params,omitempty"`
+}
+
+//Events subscribes to the api's server-sent event stream, the channel is closed when the stream
+//ends or the context is cancelled
+func (c *Client) Events(ctx context.Context) (<-chan Event, error) {
+	resp, err := c.restClient.R().
+		SetContext(ctx).
+		SetHeader("Accept", "text/event-stream").
+		SetDoNotParseResponse(true).
+		Get("/events")
+	if err != nil {
+		return nil, &RequestError{
+			Err:     err,
+			Message: err.Error(),
+		}
+	}
+	body := resp.RawBody()
+	if resp.StatusCode() != http.StatusOK {
+		defer body.Close()
+		requestErr := &RequestError{
+			Status:  resp.StatusCode(),
+			Message: http.StatusText(resp.StatusCode()),
+		}
+		if raw, err := ioutil.ReadAll(body); err == nil {
+			var apiErr RequestError
+			if err := json.Unmarshal(raw, &apiErr); err == nil && apiErr.Message != "" {
+				requestErr.Message = apiErr.Message
+			} else if message := strings.TrimSpace(string(raw)); message != "" {
+				requestErr.Message = message
+			}
+		}
+		return nil, requestErr
+	}
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer body.Close()
+		scanner := bufio.NewScanner(body)
+		eventType := ""
+		data := make([]string, 0)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if len(data) > 0 {
+					event := Event{}
+					if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &event); err == nil {
+						if event.Type == "" {
+							event.Type = eventType
+						}
+						if event.Time.IsZero() {
+							event.Time = time.Now()
+						}
+						select {
+						case events <- event:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+				eventType = ""
+				data = data[:0]
+			case strings.HasPrefix(line, "event:"):
+				eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,48 @@
+package mnapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type ExecRequest struct {
+	Command []string `json:"command"`
+	Timeout float64  `json:"timeout,omitempty"`
+}
+
+type ExecResult struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+}
+
+//Exec runs a command on the named node and waits for it to exit or for the context to be cancelled,
+//a timeout of 0 leaves the command to run until it exits
+func (c *Client) Exec(ctx context.Context, nodeName string, command []string, timeout time.Duration) (*ExecResult, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no command provided to exec")
+	}
+	//the api enforces the timeout, allow a little longer for its response to arrive
+	requestTimeout := time.Duration(0)
+	if timeout > 0 {
+		requestTimeout = timeout + (5 * time.Second)
+	}
+	var result *ExecResult
+	request, cancel := c.requestContext(ctx, requestTimeout)
+	defer cancel()
+	resp, err := request.
+		SetPathParam("node_name", nodeName).
+		SetBody(ExecRequest{
+			Command: command,
+			Timeout: timeout.Seconds(),
+		}).SetResult(&result).
+		Post("/node/{node_name}/exec")
+	if err := checkResponse(resp, err); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("no exec result received for node %s", nodeName)
+	}
+	return result, nil
+}
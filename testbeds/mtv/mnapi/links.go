@@ -0,0 +1,66 @@
+package mnapi
+
+const (
+	LinkUp   string = "up"
+	LinkDown string = "down"
+)
+
+//LinkParams are the traffic control parameters of a link,
+//zero values mean the parameter is not applied
+type LinkParams struct {
+	Bandwidth float64 `json:"bw,omitempty"`
+	Delay     string  `json:"delay,omitempty"`
+	Jitter    string  `json:"jitter,omitempty"`
+	Loss      float64 `json:"loss,omitempty"`
+}
+
+type LinkInfo struct {
+	Node1      string     `json:"node1"`
+	Node2      string     `json:"node2"`
+	Interface1 string     `json:"intf1"`
+	Interface2 string     `json:"intf2"`
+	Status     string     `json:"status"`
+	Params     LinkParams `json:"params"`
+}
+
+func (c *Client) GetLinks() ([]*LinkInfo, error) {
+	var links []*LinkInfo
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
+		SetResult(&links).Get("/links")
+	if err := checkResponse(resp, err); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+//SetLinkStatus brings the link between the two nodes up or down
+func (c *Client) SetLinkStatus(node1, node2 string, up bool) error {
+	status := LinkDown
+	if up {
+		status = LinkUp
+	}
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
+		SetPathParams(map[string]string{
+			"node1": node1,
+			"node2": node2,
+		}).SetQueryParam("status", status).
+		Put("/link/{node1}/{node2}/status")
+	return checkResponse(resp, err)
+}
+
+//SetLinkParams replaces all traffic control parameters of the link between the two nodes
+func (c *Client) SetLinkParams(node1, node2 string, params LinkParams) error {
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
+		SetPathParams(map[string]string{
+			"node1": node1,
+			"node2": node2,
+		}).SetBody(params).
+		Put("/link/{node1}/{node2}/params")
+	return checkResponse(resp, err)
+}
@@ -1,33 +1,154 @@
 package mnapi
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
-const defaultAPIPrefix string = "/mn/api"
+const (
+	defaultAPIPrefix string        = "/mn/api"
+	defaultTimeout   time.Duration = 30 * time.Second
+)
 
+//RequestError is returned by all client methods when a request fails, a status of 0 means no
+//response was received from the api
 type RequestError struct {
-	Err     error
-	Status  int
+	Err     error  `json:"-"`
+	Status  int    `json:"-"`
 	Message string `json:"error"`
 }
 
 func (re *RequestError) Error() string {
+	if re.Status == 0 {
+		return fmt.Sprintf("request error: %s", re.Message)
+	}
 	return fmt.Sprintf("request %d error: %s", re.Status, re.Message)
 }
 
+func (re *RequestError) Unwrap() error {
+	return re.Err
+}
+
+//Temporary is true if the request may succeed when retried, such as when the api is not up yet,
+//when no response was received only refused connections and timeouts are temporary so that
+//tls, dns and url errors are reported straight away
+func (re *RequestError) Temporary() bool {
+	switch re.Status {
+	case 0:
+		var netErr net.Error
+		return errors.Is(re.Err, syscall.ECONNREFUSED) || (errors.As(re.Err, &netErr) && netErr.Timeout())
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+//IsTemporary is true if the error is a RequestError that may succeed when retried
+func IsTemporary(err error) bool {
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) {
+		return requestErr.Temporary()
+	}
+	return false
+}
+
 type Client struct {
 	baseURL    *url.URL
 	restClient *resty.Client
+	timeout    time.Duration
+}
+
+//Option configures a client when it is created
+type Option func(c *Client) error
+
+//TLSConfig configures https connections to the api, all files are pem encoded
+type TLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+//WithTimeout sets how long each call to the api may take including any retries,
+//a timeout of 0 means calls never time out
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+//WithBearerToken authenticates every request with the given token
+func WithBearerToken(token string) Option {
+	return func(c *Client) error {
+		c.restClient.SetAuthToken(token)
+		return nil
+	}
+}
+
+//WithTLS trusts the given ca as well as the system's and presents a client certificate if one is given
+func WithTLS(config TLSConfig) Option {
+	return func(c *Client) error {
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: config.InsecureSkipVerify,
+		}
+		if config.CAFile != "" {
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			caPEM, err := os.ReadFile(config.CAFile)
+			if err != nil {
+				return fmt.Errorf("failed to read ca file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(caPEM) {
+				return fmt.Errorf("no certificates found in ca file %s", config.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if config.CertFile != "" || config.KeyFile != "" {
+			if config.CertFile == "" || config.KeyFile == "" {
+				return errors.New("a client certificate needs both a cert file and a key file")
+			}
+			cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+			if err != nil {
+				return fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		c.restClient.SetTLSClientConfig(tlsConfig)
+		return nil
+	}
 }
 
-func NewClient(mnTarget string, customHeaders map[string]string) (*Client, error) {
+//WithRetries retries requests that fail with a temporary error up to count times,
+//waiting between wait and maxWait with exponential backoff between attempts,
+//requests that are not idempotent (such as exec) are never retried
+func WithRetries(count int, wait time.Duration, maxWait time.Duration) Option {
+	return func(c *Client) error {
+		c.restClient.SetRetryCount(count).
+			SetRetryWaitTime(wait).
+			SetRetryMaxWaitTime(maxWait)
+		return nil
+	}
+}
+
+func NewClient(mnTarget string, customHeaders map[string]string, options ...Option) (*Client, error) {
 	client := Client{
 		restClient: resty.New(),
+		timeout:    defaultTimeout,
 	}
 	url, err := url.Parse(mnTarget)
 	if err != nil {
@@ -40,9 +161,64 @@ func NewClient(mnTarget string, customHeaders map[string]string) (*Client, error
 	client.restClient.SetHostURL(mnTarget + defaultAPIPrefix)
 	client.restClient.SetError(&RequestError{})
 	client.restClient.SetHeaders(customHeaders)
+	client.restClient.AddRetryCondition(retryCondition)
+	for _, option := range options {
+		if err := option(&client); err != nil {
+			return nil, err
+		}
+	}
 	return &client, nil
 }
 
 func (c *Client) SetPrefix(newPrefix string) {
 	c.restClient.SetHostURL(c.baseURL.String() + newPrefix)
 }
+
+//request gives a new request that is cancelled after the timeout, a timeout of 0 means it is never cancelled
+func (c *Client) request(timeout time.Duration) (*resty.Request, context.CancelFunc) {
+	return c.requestContext(context.Background(), timeout)
+}
+
+//requestContext gives a new request that is cancelled with the context or after the timeout
+func (c *Client) requestContext(ctx context.Context, timeout time.Duration) (*resty.Request, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return c.restClient.R().SetContext(ctx).SetHeader("Accept", "application/json"), cancel
+}
+
+func retryCondition(resp *resty.Response, err error) bool {
+	if resp != nil && resp.Request != nil && resp.Request.Method == http.MethodPost {
+		return false
+	}
+	if err != nil {
+		return (&RequestError{Err: err}).Temporary()
+	}
+	return (&RequestError{Status: resp.StatusCode()}).Temporary()
+}
+
+//checkResponse converts a failed request or a non-200 response into a RequestError,
+//using the api's error message when it gives one
+func checkResponse(resp *resty.Response, err error) error {
+	if err != nil {
+		return &RequestError{
+			Err:     err,
+			Message: err.Error(),
+		}
+	}
+	if resp.StatusCode() == http.StatusOK {
+		return nil
+	}
+	requestErr := &RequestError{
+		Status: resp.StatusCode(),
+	}
+	if apiErr, ok := resp.Error().(*RequestError); ok && apiErr.Message != "" {
+		requestErr.Message = apiErr.Message
+	} else if body := strings.TrimSpace(resp.String()); body != "" {
+		requestErr.Message = body
+	} else {
+		requestErr.Message = http.StatusText(resp.StatusCode())
+	}
+	return requestErr
+}
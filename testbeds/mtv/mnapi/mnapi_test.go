@@ -0,0 +1,268 @@
+package mnapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/willfantom/neat/testbeds/mtv/mnapi"
+	"github.com/willfantom/neat/testbeds/mtv/mnapi/mnapitest"
+)
+
+//newServer gives a fake api serving a line of h1 - s1 - h2 with h3 attached to s1 by a link that is down
+func newServer(t *testing.T) *mnapitest.Server {
+	server := mnapitest.NewServer(mnapitest.Topology{
+		Nodes: []mnapi.NodeInfo{
+			{Name: "h1", Class: "Host"},
+			{Name: "h2", Class: "Host"},
+			{Name: "h3", Class: "Host"},
+			{Name: "s1", Class: "OVSSwitch"},
+		},
+		Links: []mnapi.LinkInfo{
+			{Node1: "h1", Node2: "s1"},
+			{Node1: "s1", Node2: "h2"},
+			{Node1: "s1", Node2: "h3", Status: mnapi.LinkDown},
+		},
+	})
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newClient(t *testing.T, server *mnapitest.Server, options ...mnapi.Option) *mnapi.Client {
+	client, err := server.Client(options...)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestNodes(t *testing.T) {
+	client := newClient(t, newServer(t))
+	nodes, err := client.GetNodes()
+	if err != nil {
+		t.Fatalf("failed to get nodes: %v", err)
+	}
+	if len(nodes) == 0 {
+		t.Errorf("no nodes were given")
+	}
+	node, err := client.GetNodeInfo("h1")
+	if err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if node.Name != "h1" || node.Class != "Host" {
+		t.Errorf("unexpected node info: %+v", node)
+	}
+}
+
+func TestPingSetFollowsLinks(t *testing.T) {
+	client := newClient(t, newServer(t))
+	pings, err := client.PingSet(context.Background(), []string{"h1", "h2", "h3"})
+	if err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	received := make(map[string]int)
+	for _, ping := range pings {
+		received[ping.Sender+"->"+ping.Target] = ping.Received
+	}
+	expected := map[string]int{
+		"h1->h2": 1, "h2->h1": 1,
+		"h1->h3": 0, "h3->h1": 0,
+		"h2->h3": 0, "h3->h2": 0,
+	}
+	for pair, count := range expected {
+		if got, ok := received[pair]; !ok {
+			t.Errorf("no ping result for %s", pair)
+		} else if got != count {
+			t.Errorf("ping %s received %d, expected %d", pair, got, count)
+		}
+	}
+}
+
+func TestLinkControl(t *testing.T) {
+	server := newServer(t)
+	client := newClient(t, server)
+	if err := client.SetLinkStatus("s1", "h3", true); err != nil {
+		t.Fatalf("failed to set link status: %v", err)
+	}
+	params := mnapi.LinkParams{Bandwidth: 10, Delay: "5ms", Loss: 1}
+	if err := client.SetLinkParams("h1", "s1", params); err != nil {
+		t.Fatalf("failed to set link params: %v", err)
+	}
+	links, err := client.GetLinks()
+	if err != nil {
+		t.Fatalf("failed to get links: %v", err)
+	}
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links, got %d", len(links))
+	}
+	if link, _ := server.Link("h3", "s1"); link.Status != mnapi.LinkUp {
+		t.Errorf("link s1-h3 was not brought up")
+	}
+	if link, _ := server.Link("h1", "s1"); link.Params != params {
+		t.Errorf("link h1-s1 params were not set: %+v", link.Params)
+	}
+	if err := client.SetLinkStatus("h1", "h2", false); err == nil {
+		t.Errorf("expected an error for a link that does not exist")
+	}
+}
+
+func TestExec(t *testing.T) {
+	server := newServer(t)
+	server.SetExecHandler(func(node string, command []string) mnapi.ExecResult {
+		return mnapi.ExecResult{Stdout: node + ":" + command[0], ExitCode: 3}
+	})
+	result, err := newClient(t, server).Exec(context.Background(), "h1", []string{"hostname"}, time.Second)
+	if err != nil {
+		t.Fatalf("failed to exec: %v", err)
+	}
+	if result.Stdout != "h1:hostname" || result.ExitCode != 3 {
+		t.Errorf("unexpected exec result: %+v", result)
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	server := newServer(t)
+	client := newClient(t, server, mnapi.WithRetries(0, 0, 0))
+
+	_, err := client.GetNodeInfo("h9")
+	var requestErr *mnapi.RequestError
+	if !errors.As(err, &requestErr) || requestErr.Status != http.StatusNotFound {
+		t.Fatalf("expected a not found request error, got %v", err)
+	}
+	if mnapi.IsTemporary(err) {
+		t.Errorf("not found should not be temporary")
+	}
+
+	server.Fail(mnapitest.RouteNodes, http.StatusServiceUnavailable, "not ready", 0)
+	_, err = client.GetNodes()
+	if !mnapi.IsTemporary(err) {
+		t.Errorf("service unavailable should be temporary, got %v", err)
+	}
+}
+
+func TestConnectionErrors(t *testing.T) {
+	server := newServer(t)
+	insecure, err := mnapi.NewClient(strings.Replace(server.URL(), "http://", "https://", 1), nil, mnapi.WithRetries(3, time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := insecure.GetNodes(); err == nil || mnapi.IsTemporary(err) {
+		t.Errorf("a tls failure should not be temporary, got %v", err)
+	}
+
+	closed := mnapitest.NewServer(mnapitest.Topology{})
+	client := newClient(t, closed, mnapi.WithRetries(0, 0, 0))
+	closed.Close()
+	if _, err := client.GetNodes(); err == nil || !mnapi.IsTemporary(err) {
+		t.Errorf("a refused connection should be temporary, got %v", err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	server := newServer(t)
+	client := newClient(t, server, mnapi.WithRetries(3, time.Millisecond, 10*time.Millisecond))
+
+	server.Fail(mnapitest.RouteNodes, http.StatusServiceUnavailable, "not ready", 2)
+	if _, err := client.GetNodes(); err != nil {
+		t.Fatalf("request was not retried: %v", err)
+	}
+
+	server.Fail(mnapitest.RouteExec, http.StatusServiceUnavailable, "not ready", 1)
+	if _, err := client.Exec(context.Background(), "h1", []string{"hostname"}, time.Second); err == nil {
+		t.Errorf("expected the exec to fail rather than be retried")
+	}
+
+	counts := make(map[mnapitest.Route]int)
+	for _, route := range server.Requests() {
+		counts[route]++
+	}
+	if counts[mnapitest.RouteNodes] != 3 {
+		t.Errorf("expected 3 node requests, got %d", counts[mnapitest.RouteNodes])
+	}
+	if counts[mnapitest.RouteExec] != 1 {
+		t.Errorf("expected 1 exec request, got %d", counts[mnapitest.RouteExec])
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	server := newServer(t)
+	server.RequireToken("secret")
+
+	_, err := newClient(t, server, mnapi.WithRetries(0, 0, 0)).GetNodes()
+	var requestErr *mnapi.RequestError
+	if !errors.As(err, &requestErr) || requestErr.Status != http.StatusUnauthorized {
+		t.Errorf("expected an unauthorized request error, got %v", err)
+	}
+	if _, err := newClient(t, server, mnapi.WithBearerToken("secret")).GetNodes(); err != nil {
+		t.Errorf("request with the token failed: %v", err)
+	}
+}
+
+func TestVNFBoot(t *testing.T) {
+	server := newServer(t)
+	server.SetVNFBootDelay(50 * time.Millisecond)
+	server.AddVNF(mnapi.VNFInfo{Name: "vnf1", State: "shut off"})
+	client := newClient(t, server)
+
+	vnf, err := client.ControlVNF("vnf1", mnapi.VNFStart)
+	if err != nil {
+		t.Fatalf("failed to start vnf: %v", err)
+	}
+	if vnf.Booted {
+		t.Errorf("vnf should not be booted straight after starting")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if vnf, err = client.GetVNF("vnf1"); err != nil {
+		t.Fatalf("failed to get vnf: %v", err)
+	} else if !vnf.Booted {
+		t.Errorf("vnf should have booted after the boot delay")
+	}
+	if _, err := client.ControlVNF("vnf1", "pause"); err == nil {
+		t.Errorf("expected an invalid action to be rejected")
+	}
+}
+
+func TestEvents(t *testing.T) {
+	server := newServer(t)
+	server.SetReady(false)
+	client := newClient(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.Events(ctx)
+	if err != nil {
+		t.Fatalf("failed to subscribe to events: %v", err)
+	}
+	server.SetReady(true)
+	if err := client.SetLinkStatus("h1", "s1", false); err != nil {
+		t.Fatalf("failed to set link status: %v", err)
+	}
+
+	expected := []string{mnapi.EventReady, mnapi.EventLinkDown}
+	for _, eventType := range expected {
+		select {
+		case event := <-events:
+			if event.Type != eventType {
+				t.Fatalf("expected a %s event, got %+v", eventType, event)
+			}
+			if eventType == mnapi.EventLinkDown && (event.Node1 != "h1" || event.Node2 != "s1") {
+				t.Errorf("link event is for the wrong link: %+v", event)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for a %s event", eventType)
+		}
+	}
+
+	cancel()
+	select {
+	case _, open := <-events:
+		if open {
+			t.Errorf("expected no more events once cancelled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("event channel was not closed once cancelled")
+	}
+}
@@ -0,0 +1,645 @@
+//Package mnapitest provides an in-process fake of the MTV api for use in tests
+package mnapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willfantom/neat/testbeds/mtv/mnapi"
+)
+
+const apiPrefix string = "/mn/api"
+
+//Route names an endpoint of the api so that failures can be injected into it
+type Route string
+
+const (
+	RouteNodes      Route = "nodes"
+	RouteNode       Route = "node"
+	RoutePingAll    Route = "pingall"
+	RoutePingSet    Route = "pingset"
+	RouteLinks      Route = "links"
+	RouteLinkStatus Route = "link_status"
+	RouteLinkParams Route = "link_params"
+	RouteExec       Route = "exec"
+	RouteVNFs       Route = "vnfs"
+	RouteVNF        Route = "vnf"
+	RouteVNFAction  Route = "vnf_action"
+	RouteEvents     Route = "events"
+)
+
+const (
+	vnfRunning string = "running"
+	vnfShutOff string = "shut off"
+)
+
+//Topology is the emulated network the server reports, links are up unless their status is "down"
+type Topology struct {
+	Nodes []mnapi.NodeInfo
+	Links []mnapi.LinkInfo
+	VNFs  []mnapi.VNFInfo
+}
+
+//ExecHandler gives the result of running a command on a node
+type ExecHandler func(node string, command []string) mnapi.ExecResult
+
+type vnf struct {
+	info      mnapi.VNFInfo
+	startedAt time.Time
+}
+
+type failure struct {
+	status  int
+	message string
+	count   int
+}
+
+//Server is a fake MTV api, by default pings succeed between nodes that are connected by links that are up
+type Server struct {
+	server *httptest.Server
+	lock   sync.Mutex
+
+	nodes    map[string]*mnapi.NodeInfo
+	links    []*mnapi.LinkInfo
+	pings    map[string]*mnapi.PingData
+	exec     ExecHandler
+	failures map[Route]*failure
+	requests []Route
+	token    string
+
+	vnfs      map[string]*vnf
+	bootDelay time.Duration
+
+	ready       bool
+	subscribers map[chan mnapi.Event]bool
+	done        chan struct{}
+}
+
+//NewServer starts a fake api serving the given topology, it must be closed when no longer needed
+func NewServer(topology Topology) *Server {
+	s := &Server{
+		nodes:    make(map[string]*mnapi.NodeInfo),
+		links:    make([]*mnapi.LinkInfo, 0, len(topology.Links)),
+		pings:    make(map[string]*mnapi.PingData),
+		failures: make(map[Route]*failure),
+		requests: make([]Route, 0),
+		vnfs:     make(map[string]*vnf),
+
+		ready:       true,
+		subscribers: make(map[chan mnapi.Event]bool),
+		done:        make(chan struct{}),
+	}
+	for _, node := range topology.Nodes {
+		s.AddNode(node)
+	}
+	for _, link := range topology.Links {
+		s.AddLink(link)
+	}
+	for _, info := range topology.VNFs {
+		s.AddVNF(info)
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+//URL gives the target to pass to mnapi.NewClient
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+func (s *Server) Close() {
+	close(s.done)
+	s.server.Close()
+}
+
+//Client gives an mnapi client for the server
+func (s *Server) Client(options ...mnapi.Option) (*mnapi.Client, error) {
+	return mnapi.NewClient(s.URL(), nil, options...)
+}
+
+func (s *Server) AddNode(node mnapi.NodeInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nodes[node.Name] = &node
+}
+
+func (s *Server) AddLink(link mnapi.LinkInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if link.Status == "" {
+		link.Status = mnapi.LinkUp
+	}
+	s.links = append(s.links, &link)
+}
+
+//AddVNF adds a vnf that is running unless its state is "shut off", running vnfs boot after the boot delay
+func (s *Server) AddVNF(info mnapi.VNFInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if info.State == "" {
+		info.State = vnfRunning
+	}
+	if info.Domain == "" {
+		info.Domain = "mtv-" + info.Name
+	}
+	s.vnfs[info.Name] = &vnf{
+		info:      info,
+		startedAt: time.Now(),
+	}
+}
+
+//SetVNFBootDelay sets how long vnfs take to boot after they start, by default they boot immediately
+func (s *Server) SetVNFBootDelay(delay time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.bootDelay = delay
+}
+
+//SetReady sets whether the topology has started, event subscribers are sent a ready event when it becomes
+//ready or when they subscribe to a server that is already ready, servers are ready by default
+func (s *Server) SetReady(ready bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if ready && !s.ready {
+		s.emit(mnapi.Event{Type: mnapi.EventReady})
+	}
+	s.ready = ready
+}
+
+//Emit sends the event to all subscribers of the event stream
+func (s *Server) Emit(event mnapi.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.emit(event)
+}
+
+//emit must be called with the lock held, subscribers that are not keeping up miss events
+func (s *Server) emit(event mnapi.Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+//Link gives a copy of the current state of the link between the two nodes
+func (s *Server) Link(node1, node2 string) (mnapi.LinkInfo, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if link := s.findLink(node1, node2); link != nil {
+		return *link, true
+	}
+	return mnapi.LinkInfo{}, false
+}
+
+//SetPing fixes the result of pings from sender to target rather than working it out from the topology
+func (s *Server) SetPing(sender, target string, data mnapi.PingData) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data.Sender = sender
+	data.Target = target
+	s.pings[pingKey(sender, target)] = &data
+}
+
+//SetExecHandler sets how commands run on nodes respond, by default they exit 0 with no output
+func (s *Server) SetExecHandler(handler ExecHandler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.exec = handler
+}
+
+//RequireToken rejects requests that do not give the bearer token, an empty token accepts all requests
+func (s *Server) RequireToken(token string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.token = token
+}
+
+//Fail makes the next count requests to the route fail with the given status and message,
+//a count of 0 makes every request to the route fail until Recover is called
+func (s *Server) Fail(route Route, status int, message string, count int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failures[route] = &failure{
+		status:  status,
+		message: message,
+		count:   count,
+	}
+}
+
+func (s *Server) Recover(route Route) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.failures, route)
+}
+
+//Requests gives the routes of all requests received so far in order
+func (s *Server) Requests() []Route {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Route{}, s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	route, params, ok := s.accept(w, r)
+	if !ok {
+		s.lock.Unlock()
+		return
+	}
+	if route == RouteEvents {
+		//the stream is long lived so must not hold the lock
+		events := s.subscribe()
+		s.lock.Unlock()
+		s.streamEvents(w, r, events)
+		return
+	}
+	defer s.lock.Unlock()
+	switch route {
+	case RouteNodes:
+		s.handleNodes(w, r)
+	case RouteNode:
+		s.handleNode(w, params[0])
+	case RoutePingAll:
+		s.handlePingAll(w)
+	case RoutePingSet:
+		s.handlePingSet(w, r)
+	case RouteLinks:
+		s.handleLinks(w)
+	case RouteLinkStatus:
+		s.handleLinkStatus(w, r, params[0], params[1])
+	case RouteLinkParams:
+		s.handleLinkParams(w, r, params[0], params[1])
+	case RouteExec:
+		s.handleExec(w, r, params[0])
+	case RouteVNFs:
+		s.handleVNFs(w)
+	case RouteVNF:
+		s.handleVNF(w, params[0])
+	case RouteVNFAction:
+		s.handleVNFAction(w, params[0], params[1])
+	}
+}
+
+//accept records the request and checks it can be handled, writing an error response if it can not
+func (s *Server) accept(w http.ResponseWriter, r *http.Request) (Route, []string, bool) {
+	if !strings.HasPrefix(r.URL.Path, apiPrefix+"/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return "", nil, false
+	}
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, apiPrefix+"/"), "/")
+	route, params := matchRoute(r.Method, segments)
+	if route == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return "", nil, false
+	}
+	s.requests = append(s.requests, route)
+	if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return "", nil, false
+	}
+	if failure, ok := s.failures[route]; ok {
+		if failure.count > 0 {
+			failure.count--
+			if failure.count == 0 {
+				delete(s.failures, route)
+			}
+		}
+		writeError(w, failure.status, failure.message)
+		return "", nil, false
+	}
+	return route, params, true
+}
+
+func matchRoute(method string, segments []string) (Route, []string) {
+	switch {
+	case method == http.MethodGet && len(segments) == 1 && segments[0] == "nodes":
+		return RouteNodes, nil
+	case method == http.MethodGet && len(segments) == 2 && segments[0] == "node":
+		return RouteNode, segments[1:]
+	case method == http.MethodGet && len(segments) == 1 && segments[0] == "pingall":
+		return RoutePingAll, nil
+	case method == http.MethodGet && len(segments) == 1 && segments[0] == "pingset":
+		return RoutePingSet, nil
+	case method == http.MethodGet && len(segments) == 1 && segments[0] == "links":
+		return RouteLinks, nil
+	case method == http.MethodPut && len(segments) == 4 && segments[0] == "link" && segments[3] == "status":
+		return RouteLinkStatus, segments[1:3]
+	case method == http.MethodPut && len(segments) == 4 && segments[0] == "link" && segments[3] == "params":
+		return RouteLinkParams, segments[1:3]
+	case method == http.MethodPost && len(segments) == 3 && segments[0] == "node" && segments[2] == "exec":
+		return RouteExec, segments[1:2]
+	case method == http.MethodGet && len(segments) == 1 && segments[0] == "events":
+		return RouteEvents, nil
+	case method == http.MethodGet && len(segments) == 1 && segments[0] == "vnfs":
+		return RouteVNFs, nil
+	case method == http.MethodGet && len(segments) == 2 && segments[0] == "vnf":
+		return RouteVNF, segments[1:]
+	case method == http.MethodPost && len(segments) == 3 && segments[0] == "vnf":
+		return RouteVNFAction, segments[1:3]
+	}
+	return "", nil
+}
+
+func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	if class := r.URL.Query().Get("class"); class != "" {
+		nodes := make(map[string]*mnapi.NodeInfo)
+		for name, node := range s.nodes {
+			if node.Class == class {
+				nodes[name] = node
+			}
+		}
+		writeJSON(w, nodes)
+		return
+	}
+	nodes := make(map[string][]string)
+	for name, node := range s.nodes {
+		nodes[node.Class] = append(nodes[node.Class], name)
+	}
+	writeJSON(w, nodes)
+}
+
+func (s *Server) handleNode(w http.ResponseWriter, name string) {
+	node, ok := s.nodes[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "node "+name+" does not exist")
+		return
+	}
+	writeJSON(w, node)
+}
+
+func (s *Server) handlePingAll(w http.ResponseWriter) {
+	pings := make([]*mnapi.PingData, 0)
+	for sender := range s.nodes {
+		for target := range s.nodes {
+			if sender != target {
+				pings = append(pings, s.ping(sender, target))
+			}
+		}
+	}
+	writeJSON(w, pings)
+}
+
+func (s *Server) handlePingSet(w http.ResponseWriter, r *http.Request) {
+	hosts := strings.Split(r.URL.Query().Get("hosts"), ",")
+	pings := make(map[string]*mnapi.PingData)
+	for _, sender := range hosts {
+		if _, ok := s.nodes[sender]; !ok {
+			writeError(w, http.StatusNotFound, "node "+sender+" does not exist")
+			return
+		}
+		//results are keyed by pair so none are lost when more than two hosts are given
+		for _, target := range hosts {
+			if sender != target {
+				pings[pingKey(sender, target)] = s.ping(sender, target)
+			}
+		}
+	}
+	writeJSON(w, pings)
+}
+
+func (s *Server) handleLinks(w http.ResponseWriter) {
+	writeJSON(w, s.links)
+}
+
+func (s *Server) handleLinkStatus(w http.ResponseWriter, r *http.Request, node1, node2 string) {
+	link := s.findLink(node1, node2)
+	if link == nil {
+		writeError(w, http.StatusNotFound, "no link between "+node1+" and "+node2)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status != mnapi.LinkUp && status != mnapi.LinkDown {
+		writeError(w, http.StatusBadRequest, "status must be up or down")
+		return
+	}
+	link.Status = status
+	eventType := mnapi.EventLinkDown
+	if status == mnapi.LinkUp {
+		eventType = mnapi.EventLinkUp
+	}
+	s.emit(mnapi.Event{Type: eventType, Node1: link.Node1, Node2: link.Node2})
+	writeJSON(w, link)
+}
+
+func (s *Server) handleLinkParams(w http.ResponseWriter, r *http.Request, node1, node2 string) {
+	link := s.findLink(node1, node2)
+	if link == nil {
+		writeError(w, http.StatusNotFound, "no link between "+node1+" and "+node2)
+		return
+	}
+	var params mnapi.LinkParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	link.Params = params
+	s.emit(mnapi.Event{Type: mnapi.EventLinkChanged, Node1: link.Node1, Node2: link.Node2, Params: &params})
+	writeJSON(w, link)
+}
+
+func (s *Server) handleExec(w http.ResponseWriter, r *http.Request, node string) {
+	if _, ok := s.nodes[node]; !ok {
+		writeError(w, http.StatusNotFound, "node "+node+" does not exist")
+		return
+	}
+	var request mnapi.ExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result := mnapi.ExecResult{}
+	if s.exec != nil {
+		result = s.exec(node, request.Command)
+	}
+	writeJSON(w, result)
+}
+
+func (s *Server) handleVNFs(w http.ResponseWriter) {
+	names := make([]string, 0, len(s.vnfs))
+	for name := range s.vnfs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	vnfs := make([]mnapi.VNFInfo, 0, len(names))
+	for _, name := range names {
+		vnfs = append(vnfs, s.vnfInfo(s.vnfs[name]))
+	}
+	writeJSON(w, vnfs)
+}
+
+func (s *Server) handleVNF(w http.ResponseWriter, name string) {
+	vnf, ok := s.vnfs[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "vnf "+name+" does not exist")
+		return
+	}
+	writeJSON(w, s.vnfInfo(vnf))
+}
+
+func (s *Server) handleVNFAction(w http.ResponseWriter, name string, action string) {
+	vnf, ok := s.vnfs[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "vnf "+name+" does not exist")
+		return
+	}
+	switch action {
+	case mnapi.VNFStart:
+		if vnf.info.State != vnfRunning {
+			s.bootVNF(vnf)
+		}
+	case mnapi.VNFStop:
+		vnf.info.State = vnfShutOff
+		s.emit(mnapi.Event{Type: mnapi.EventVNFStopped, VNF: vnf.info.Name})
+	case mnapi.VNFReboot:
+		s.bootVNF(vnf)
+	default:
+		writeError(w, http.StatusBadRequest, "vnf action "+action+" is not valid")
+		return
+	}
+	writeJSON(w, s.vnfInfo(vnf))
+}
+
+//bootVNF starts the vnf, sending a booted event once the boot delay has passed if it was not restarted since
+func (s *Server) bootVNF(vnf *vnf) {
+	vnf.info.State = vnfRunning
+	startedAt := time.Now()
+	vnf.startedAt = startedAt
+	time.AfterFunc(s.bootDelay, func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if vnf.startedAt.Equal(startedAt) && vnf.info.State == vnfRunning {
+			s.emit(mnapi.Event{Type: mnapi.EventVNFBooted, VNF: vnf.info.Name})
+		}
+	})
+}
+
+func (s *Server) vnfInfo(vnf *vnf) mnapi.VNFInfo {
+	info := vnf.info
+	info.Booted = info.State == vnfRunning && time.Since(vnf.startedAt) >= s.bootDelay
+	return info
+}
+
+//subscribe must be called with the lock held
+func (s *Server) subscribe() chan mnapi.Event {
+	events := make(chan mnapi.Event, 64)
+	s.subscribers[events] = true
+	if s.ready {
+		events <- mnapi.Event{Type: mnapi.EventReady, Time: time.Now()}
+	}
+	return events
+}
+
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, events chan mnapi.Event) {
+	defer func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.subscribers, events)
+	}()
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+//ping gives the fixed result for the pair if one is set, otherwise a single packet is received if
+//the nodes are connected
+func (s *Server) ping(sender, target string) *mnapi.PingData {
+	if data, ok := s.pings[pingKey(sender, target)]; ok {
+		return data
+	}
+	data := &mnapi.PingData{
+		Sender: sender,
+		Target: target,
+		Sent:   1,
+	}
+	if s.connected(sender, target) {
+		data.Received = 1
+	}
+	return data
+}
+
+func (s *Server) connected(sender, target string) bool {
+	visited := map[string]bool{sender: true}
+	queue := []string{sender}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == target {
+			return true
+		}
+		for _, link := range s.links {
+			if link.Status != mnapi.LinkUp {
+				continue
+			}
+			next := ""
+			if link.Node1 == current {
+				next = link.Node2
+			} else if link.Node2 == current {
+				next = link.Node1
+			}
+			if next != "" && !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) findLink(node1, node2 string) *mnapi.LinkInfo {
+	for _, link := range s.links {
+		if (link.Node1 == node1 && link.Node2 == node2) || (link.Node1 == node2 && link.Node2 == node1) {
+			return link
+		}
+	}
+	return nil
+}
+
+func pingKey(sender, target string) string {
+	return sender + "->" + target
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(mnapi.RequestError{Message: message})
+}
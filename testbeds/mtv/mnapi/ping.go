@@ -1,7 +1,7 @@
 package mnapi
 
 import (
-	"fmt"
+	"context"
 	"strings"
 )
 
@@ -15,31 +15,27 @@ type PingData struct {
 
 func (c *Client) PingAll() ([]*PingData, error) {
 	var pingData []*PingData
-	resp, err := c.restClient.R().
-		SetHeader("Accept", "application/json").
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
 		SetResult(&pingData).Get("/pingall")
-	if err != nil {
+	if err := checkResponse(resp, err); err != nil {
 		return nil, err
 	}
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("received non-200 status code (%d)", resp.StatusCode())
-	}
 	return pingData, nil
 }
 
-func (c *Client) PingSet(nodes []string) (map[string]*PingData, error) {
+func (c *Client) PingSet(ctx context.Context, nodes []string) (map[string]*PingData, error) {
 	nodeParam := strings.Join(nodes, ",")
 	var pingData map[string]*PingData
-	resp, err := c.restClient.R().
-		SetHeader("Accept", "application/json").
+	request, cancel := c.requestContext(ctx, c.timeout)
+	defer cancel()
+	resp, err := request.
 		SetResult(&pingData).SetQueryParam("hosts", nodeParam).
 		Get("/pingset")
-	if err != nil {
+	if err := checkResponse(resp, err); err != nil {
 		return nil, err
 	}
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("received non-200 status code (%d)", resp.StatusCode())
-	}
 	return pingData, nil
 
 }
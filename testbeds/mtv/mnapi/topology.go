@@ -1,7 +1,5 @@
 package mnapi
 
-import "fmt"
-
 type NodeInfo struct {
 	Name  string   `json:"name"`
 	Class string   `json:"class"`
@@ -12,44 +10,38 @@ type NodeInfo struct {
 func (c *Client) GetNodes() (map[string][]string, error) {
 	// var nodes map[string]*NodeInfo
 	var nodes map[string][]string
-	resp, err := c.restClient.R().
-		SetHeader("Accept", "application/json").
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
 		SetResult(&nodes).Get("/nodes")
-	if err != nil {
+	if err := checkResponse(resp, err); err != nil {
 		return nil, err
 	}
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("received non-200 status code (%d)", resp.StatusCode())
-	}
 	return nodes, nil
 }
 
 func (c *Client) GetNodesOfClass(class string) (map[string]*NodeInfo, error) {
 	var nodes map[string]*NodeInfo
-	resp, err := c.restClient.R().
-		SetHeader("Accept", "application/json").
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
 		SetResult(&nodes).SetQueryParam("class", class).
 		Get("/nodes")
-	if err != nil {
+	if err := checkResponse(resp, err); err != nil {
 		return nil, err
 	}
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("received non-200 status code (%d)", resp.StatusCode())
-	}
 	return nodes, nil
 }
 
 func (c *Client) GetNodeInfo(nodeName string) (*NodeInfo, error) {
 	var node *NodeInfo
-	resp, err := c.restClient.R().
-		SetHeader("Accept", "application/json").
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
 		SetResult(&node).SetPathParam("node_name", nodeName).
 		Get("/node/{node_name}")
-	if err != nil {
+	if err := checkResponse(resp, err); err != nil {
 		return nil, err
 	}
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("received non-200 status code (%d)", resp.StatusCode())
-	}
 	return node, nil
 }
@@ -0,0 +1,65 @@
+package mnapi
+
+import "fmt"
+
+const (
+	VNFStart  string = "start"
+	VNFStop   string = "stop"
+	VNFReboot string = "reboot"
+)
+
+//VNFInfo describes a vnf node backed by a libvirt domain, booted is only true once the vm's
+//operating system has finished starting rather than when the domain is running
+type VNFInfo struct {
+	Name   string   `json:"name"`
+	Domain string   `json:"domain"`
+	State  string   `json:"state"`
+	Booted bool     `json:"booted"`
+	IPs    []string `json:"ips,omitempty"`
+}
+
+func (c *Client) GetVNFs() ([]*VNFInfo, error) {
+	var vnfs []*VNFInfo
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
+		SetResult(&vnfs).Get("/vnfs")
+	if err := checkResponse(resp, err); err != nil {
+		return nil, err
+	}
+	return vnfs, nil
+}
+
+func (c *Client) GetVNF(name string) (*VNFInfo, error) {
+	var vnf *VNFInfo
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
+		SetResult(&vnf).SetPathParam("vnf_name", name).
+		Get("/vnf/{vnf_name}")
+	if err := checkResponse(resp, err); err != nil {
+		return nil, err
+	}
+	return vnf, nil
+}
+
+//ControlVNF starts, stops or reboots the vnf's domain, giving its state after the action
+func (c *Client) ControlVNF(name string, action string) (*VNFInfo, error) {
+	switch action {
+	case VNFStart, VNFStop, VNFReboot:
+	default:
+		return nil, fmt.Errorf("vnf action '%s' is not valid", action)
+	}
+	var vnf *VNFInfo
+	request, cancel := c.request(c.timeout)
+	defer cancel()
+	resp, err := request.
+		SetResult(&vnf).SetPathParams(map[string]string{
+		"vnf_name": name,
+		"action":   action,
+	}).Post("/vnf/{vnf_name}/{action}")
+	if err := checkResponse(resp, err); err != nil {
+		return nil, err
+	}
+	return vnf, nil
+}
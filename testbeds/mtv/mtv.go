@@ -1,32 +1,94 @@
 package mtv
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/sirupsen/logrus"
+	"github.com/willfantom/neat/artifacts"
 	"github.com/willfantom/neat/testbeds"
 	"github.com/willfantom/neat/testbeds/mtv/mnapi"
 	"github.com/willfantom/neat/tools/docker"
 	"github.com/willfantom/neat/types"
 )
 
-var containers = make(map[string]*docker.NeatContainer)
+var (
+	containersLock sync.Mutex
+	containers     = make(map[string]*docker.NeatContainer)
+)
+
+//testbedContainer gives the testbed's container if it has been created
+func testbedContainer(testbed *testbeds.Testbed) (*docker.NeatContainer, bool) {
+	containersLock.Lock()
+	defer containersLock.Unlock()
+	container, ok := containers[testbed.Name]
+	return container, ok
+}
 
-func validateConfiguration(config map[string]interface{}) (bool, error) {
-	parsedConfig, err := parseConfig(config)
+func validateConfiguration(testbed *testbeds.Testbed) (bool, error) {
+	parsedConfig, err := parseConfig(testbed.VariantConfig)
 	if err != nil {
 		return false, err
 	}
 	if parsedConfig.Files == "" {
 		return false, fmt.Errorf("files must be provided to an mtv testbed")
 	}
+	files := testbed.ResolvePath(parsedConfig.Files)
+	if err := testbeds.CheckPath("files", files); err != nil {
+		return false, err
+	}
+	if filepath.IsAbs(parsedConfig.Topology) || strings.HasPrefix(filepath.Clean(parsedConfig.Topology), "..") {
+		return false, fmt.Errorf("topology '%s' must be a relative path within files", parsedConfig.Topology)
+	}
+	if err := testbeds.CheckPath("topology", filepath.Join(files, parsedConfig.Topology)); err != nil {
+		return false, err
+	}
+	for host, target := range parsedConfig.Volumes {
+		hostPath := testbed.ResolvePath(host)
+		if err := testbeds.CheckPath("volumes", hostPath); err != nil {
+			return false, err
+		}
+		if hostPath == files {
+			return false, fmt.Errorf("volume '%s' is already mounted as the testbed files", host)
+		}
+		containerPath := strings.SplitN(target, ":", 2)[0]
+		if !path.IsAbs(containerPath) {
+			return false, fmt.Errorf("volume target '%s' must be an absolute path", target)
+		}
+		if path.Clean(containerPath) == filesMount {
+			return false, fmt.Errorf("volume target '%s' conflicts with the testbed files mount", target)
+		}
+	}
+	if parsedConfig.PullPolicy != "" && !docker.PullPolicy(parsedConfig.PullPolicy).Valid() {
+		return false, fmt.Errorf("pull policy '%s' is not valid (always, if-not-present or never)", parsedConfig.PullPolicy)
+	}
+	if _, err := docker.GetEngine(parsedConfig.EngineHost); err != nil {
+		return false, fmt.Errorf("container engine is unavailable: %w", err)
+	}
+	switch parsedConfig.Logs {
+	case "", logsAlways, logsOnFailure, logsNever:
+	default:
+		return false, fmt.Errorf("logs '%s' is not valid (always, on-failure or never)", parsedConfig.Logs)
+	}
+	if err := validateAPIConfig(testbed, parsedConfig.API); err != nil {
+		return false, err
+	}
+	if parsedConfig.Controller != nil {
+		if err := validateControllerConfig(parsedConfig.Controller); err != nil {
+			return false, err
+		}
+	}
 	return true, nil
 }
 
-func create(testbed *testbeds.Testbed) error {
+func create(ctx context.Context, testbed *testbeds.Testbed) error {
 	parsedConfig, err := parseConfig(testbed.VariantConfig)
 	if err != nil {
 		return err
@@ -34,142 +96,291 @@ func create(testbed *testbeds.Testbed) error {
 	if parsedConfig.Image == "" {
 		parsedConfig.Image = dockerImage
 	}
+	engine, err := docker.GetEngine(parsedConfig.EngineHost)
+	if err != nil {
+		return err
+	}
 	start := time.Now()
-	currentDir, _ := os.Getwd()
 	container := docker.NeatContainer{
-		Name:  testbed.Name,
-		Image: parsedConfig.Image,
+		Engine: engine,
+		Name:   testbed.Name,
+		Image:  parsedConfig.Image,
 		Volumes: map[string]string{
-			filepath.Join(currentDir, parsedConfig.Files): "/mnt",
+			testbed.ResolvePath(parsedConfig.Files): filesMount,
 		},
 		Labels: map[string]string{
 			"name":    testbed.Name,
 			"variant": testbed.VariantName,
+			"run":     testbeds.RunID(),
 		},
-
 		Environment: map[string]string{
-			"SCRIPT":    "/mnt/topology.py",
-			"ASSET_DIR": currentDir,
+			"SCRIPT":    path.Join(filesMount, filepath.ToSlash(parsedConfig.Topology)),
+			"ASSET_DIR": testbed.Dir(),
 		},
 		Privileged: true,
 		TTY:        true,
-		Command:    []string{},
+		Command:    parsedConfig.Command,
+	}
+	for host, target := range parsedConfig.Volumes {
+		container.Volumes[testbed.ResolvePath(host)] = target
+	}
+	//user provided environment variables take precedence over the defaults
+	for name, value := range parsedConfig.Environment {
+		container.Environment[name] = value
+	}
+	if testbed.ResourceCap.Applies() {
+		memory, err := testbed.ResourceCap.MemoryBytes()
+		if err != nil {
+			return err
+		}
+		container.Resources = docker.Resources{
+			CPUQuota:   testbed.ResourceCap.CPUQuota,
+			CPUPeriod:  testbed.ResourceCap.CPUPeriod,
+			CPUSetCPUs: testbed.ResourceCap.CPUSet,
+			Memory:     memory,
+			PidsLimit:  testbed.ResourceCap.PidsLimit,
+		}
 	}
 	if parsedConfig.Libvirt {
 		container.Volumes["/var/run/libvirt/libvirt-sock"] = "/var/run/libvirt/libvirt-sock"
 		// container.Volumes["/var/run/docker.sock"] = "/var/run/docker.sock"
 	}
 
-	if err := container.Create(); err != nil {
+	if err := container.EnsureImage(ctx, docker.PullPolicy(parsedConfig.PullPolicy)); err != nil {
+		return err
+	}
+	if digest, err := container.ImageDigest(); err == nil {
+		testbed.Metrics.ImageDigest = digest
+	}
+	network, err := acquireNetwork(engine, parsedConfig)
+	if err != nil {
 		return err
 	}
+	container.Network = network
+	if parsedConfig.Controller != nil {
+		address, err := startController(ctx, testbed, engine, parsedConfig.Controller, network)
+		if err != nil {
+			rollbackCreate(testbed, engine, network)
+			return err
+		}
+		container.Environment["CONTROLLER_IP"] = address
+		container.Environment["CONTROLLER_PORT"] = strconv.Itoa(parsedConfig.Controller.Port)
+		//user provided environment variables still take precedence
+		for name, value := range parsedConfig.Environment {
+			container.Environment[name] = value
+		}
+	}
+	if err := container.Create(ctx); err != nil {
+		rollbackCreate(testbed, engine, network)
+		return err
+	}
+	containersLock.Lock()
 	containers[testbed.Name] = &container
+	containersLock.Unlock()
 	testbed.Metrics.CreatedAt = time.Now()
 	testbed.Metrics.CreationTime = time.Since(start)
 	return nil
 }
 
-func start(testbed *testbeds.Testbed) error {
-	if container, ok := containers[testbed.Name]; !ok {
+func start(ctx context.Context, testbed *testbeds.Testbed) error {
+	if container, ok := testbedContainer(testbed); !ok {
 		return fmt.Errorf("mtv testbed has no container")
 	} else {
 		start := time.Now()
-		err := container.Start()
+		err := container.Start(ctx)
 		if err != nil {
 			return err
 		}
-		ip, err := container.GetIP()
+		client, err := apiClient(testbed, mnapi.WithRetries(0, 0, 0), mnapi.WithTimeout(5*time.Second))
 		if err != nil {
+			return fmt.Errorf("failed to create mtv client: %w", err)
+		}
+		if err := waitForReady(ctx, testbed, client); err != nil {
 			return err
 		}
-		for {
-			//TODO: don't hardcode so much of this
-			// if time.Since(start) > (300 * time.Second) {
-			// 	return fmt.Errorf("failed to start mtv api")
-			// }
-			client, err := mnapi.NewClient("http://"+ip+":8080", nil)
-			if err != nil {
-				return fmt.Errorf("failed to create mtv client")
-			}
-			if _, err = client.GetNodes(); err == nil {
-				break
+		if parsedConfig, err := parseConfig(testbed.VariantConfig); err != nil {
+			return err
+		} else if parsedConfig.Controller != nil {
+			if err := waitForSwitches(ctx, testbed, container, parsedConfig.Controller.ConnectTimeout); err != nil {
+				return err
 			}
-			time.Sleep(500 * time.Millisecond)
 		}
 		testbed.Metrics.Runs = append(testbed.Metrics.Runs, testbeds.RunMetrics{
 			StartedAt: time.Now(),
 			StartTime: time.Since(start),
 		})
+		if testbed.SampleInterval > 0 {
+			if err := container.StartSampling(testbed.SampleInterval); err != nil {
+				logrus.WithField("testbed", testbed.Name).Warnln(err.Error())
+			}
+		}
 		return nil
 	}
 }
 
-func stop(testbed *testbeds.Testbed) error {
-	if container, ok := containers[testbed.Name]; !ok {
+func stop(ctx context.Context, testbed *testbeds.Testbed) error {
+	if container, ok := testbedContainer(testbed); !ok {
 		return fmt.Errorf("mtv testbed has no container")
 	} else {
+		//samples are kept even if the container fails to stop as they cover the run up until now
+		for _, sample := range container.StopSampling() {
+			testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1].Samples = append(testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1].Samples, testbeds.ResourceSample{
+				Time:        sample.ReadTime,
+				CPUUsage:    sample.CPUPercent(),
+				MemoryUsage: sample.MemoryPercent(),
+			})
+		}
 		start := time.Now()
-		err := container.Stop()
+		err := container.Stop(ctx)
 		if err != nil {
 			return err
 		}
 		testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1].StoppedAt = time.Now()
 		testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1].StopTime = time.Since(start)
-		containerCPUUsage := container.StopStats[len(container.StopStats)-1].CPUStats.Usage.Total - container.StartStats[len(container.StartStats)-1].CPUStats.Usage.Total
-		containerMemoryUsage := float64(container.StopStats[len(container.StopStats)-1].MemoryStats.MaxUsage) / float64(container.StopStats[len(container.StopStats)-1].MemoryStats.Limit)
-		systemCPUUsage := container.StopStats[len(container.StopStats)-1].CPUStats.SystemUsage - container.StartStats[len(container.StartStats)-1].CPUStats.SystemUsage
-		testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1].CPUUsage = ((float64(containerCPUUsage) / float64(systemCPUUsage)) * float64(len(container.StopStats[len(container.StopStats)-1].CPUStats.Usage.PerCPU)) * 100)
-		testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1].PeakMemoryUsage = containerMemoryUsage * 100
+		if len(container.StartStats) > 0 && len(container.StopStats) > 0 {
+			containerCPUUsage := container.StopStats[len(container.StopStats)-1].CPUStats.Usage.Total - container.StartStats[len(container.StartStats)-1].CPUStats.Usage.Total
+			containerMemoryUsage := float64(container.StopStats[len(container.StopStats)-1].MemoryStats.MaxUsage) / float64(container.StopStats[len(container.StopStats)-1].MemoryStats.Limit)
+			systemCPUUsage := container.StopStats[len(container.StopStats)-1].CPUStats.SystemUsage - container.StartStats[len(container.StartStats)-1].CPUStats.SystemUsage
+			testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1].CPUUsage = ((float64(containerCPUUsage) / float64(systemCPUUsage)) * float64(len(container.StopStats[len(container.StopStats)-1].CPUStats.Usage.PerCPU)) * 100)
+			testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1].PeakMemoryUsage = containerMemoryUsage * 100
+		}
 		return nil
 	}
 }
 
-func remove(testbed *testbeds.Testbed) error {
-	if container, ok := containers[testbed.Name]; !ok {
+func remove(ctx context.Context, testbed *testbeds.Testbed) error {
+	if container, ok := testbedContainer(testbed); !ok {
 		return fmt.Errorf("mtv testbed has no container")
 	} else {
+		collectLogs(testbed, container)
+		//every step is attempted so that a failure does not leave the rest of the testbed behind
+		errs := make([]string, 0)
 		start := time.Now()
-		err := container.Remove()
-		if err != nil {
-			return err
+		if err := container.Remove(ctx); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			containersLock.Lock()
+			delete(containers, testbed.Name)
+			containersLock.Unlock()
+			testbed.Metrics.RemovedAt = time.Now()
+			testbed.Metrics.RemoveTime = time.Since(start)
+		}
+		stopEvents(testbed)
+		if err := removeController(ctx, testbed); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := releaseNetwork(container.Engine, container.Network); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to remove mtv testbed: %s", strings.Join(errs, ", "))
 		}
-		testbed.Metrics.RemovedAt = time.Now()
-		testbed.Metrics.RemoveTime = time.Since(start)
-
 		return nil
 	}
 }
 
-func doPing(testbed *testbeds.Testbed, request types.PingRequest) (*types.PingResponse, error) {
-	if container, ok := containers[testbed.Name]; !ok {
-		return nil, fmt.Errorf("mtv testbed has no container")
-	} else {
-		ip, err := container.GetIP()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get container ip")
-		}
-		client, err := mnapi.NewClient("http://"+ip+":8080", nil)
-		if err != nil {
-			return nil, err
+//rollbackCreate cleans up what create made before the testbed's container was created,
+//failures are only logged so the original error is reported
+func rollbackCreate(testbed *testbeds.Testbed, engine *docker.Engine, network string) {
+	logger := logrus.WithField("testbed", testbed.Name)
+	if err := removeController(context.Background(), testbed); err != nil {
+		logger.Warnln(err.Error())
+	}
+	if err := releaseNetwork(engine, network); err != nil {
+		logger.Warnln(err.Error())
+	}
+}
+
+//collectLogs saves the container's logs to the run's artifacts if the testbed's log policy requires it,
+//failures here are only logged so the container is still removed
+func collectLogs(testbed *testbeds.Testbed, container *docker.NeatContainer) {
+	parsedConfig, err := parseConfig(testbed.VariantConfig)
+	if err != nil {
+		return
+	}
+	switch parsedConfig.Logs {
+	case logsNever:
+		return
+	case logsAlways:
+	default:
+		if !testbed.Failed() {
+			return
 		}
-		pingData, err := client.PingSet([]string{request.Sender, request.Target})
-		if err != nil {
-			return nil, err
-		}
-		for _, pingResponse := range pingData {
-			if pingResponse.Sender == request.Sender {
-				return &types.PingResponse{
-					Sent:     uint(pingResponse.Sent),
-					Received: uint(pingResponse.Received),
-				}, nil
-			}
+	}
+	dir, err := artifacts.Dir("testbeds", testbed.Name)
+	if err != nil {
+		logrus.WithField("testbed", testbed.Name).Warnln(err.Error())
+		return
+	}
+	path := filepath.Join(dir, "container.log")
+	if err := container.SaveLogs(path); err != nil {
+		logrus.WithField("testbed", testbed.Name).Warnln(err.Error())
+		return
+	}
+	testbed.AddArtifact(path)
+}
+
+func doPing(ctx context.Context, testbed *testbeds.Testbed, request types.PingRequest) (*types.PingResponse, error) {
+	client, err := apiClient(testbed)
+	if err != nil {
+		return nil, err
+	}
+	pingData, err := client.PingSet(ctx, []string{request.Sender, request.Target})
+	if err != nil {
+		return nil, err
+	}
+	for _, pingResponse := range pingData {
+		if pingResponse.Sender == request.Sender {
+			return &types.PingResponse{
+				Sent:     uint(pingResponse.Sent),
+				Received: uint(pingResponse.Received),
+			}, nil
 		}
 	}
 	return nil, errors.New("failed to get ping response")
 }
 
+func doExec(ctx context.Context, testbed *testbeds.Testbed, request types.ExecRequest) (*types.ExecResponse, error) {
+	if request.Node != "" {
+		return doNodeExec(ctx, testbed, request)
+	}
+	container, ok := testbedContainer(testbed)
+	if !ok {
+		return nil, fmt.Errorf("mtv testbed has no container")
+	}
+	result, err := container.Exec(ctx, request.Command, docker.ExecOptions{
+		TTY:     request.TTY,
+		Timeout: request.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.ExecResponse{
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		ExitCode: result.ExitCode,
+	}, nil
+}
+
+//doNodeExec runs the command on an emulated node through the mtv api rather than in the container
+func doNodeExec(ctx context.Context, testbed *testbeds.Testbed, request types.ExecRequest) (*types.ExecResponse, error) {
+	client, err := apiClient(testbed)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.Exec(ctx, request.Node, request.Command, request.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ExecResponse{
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		ExitCode: result.ExitCode,
+	}, nil
+}
+
 func getArguments(path string, testbed *testbeds.Testbed) []string {
-	if container, ok := containers[testbed.Name]; !ok {
+	if container, ok := testbedContainer(testbed); !ok {
 		return []string{path}
 	} else {
 		return []string{path, container.ID}
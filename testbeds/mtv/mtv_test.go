@@ -0,0 +1,218 @@
+package mtv
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/testbeds/mtv/mnapi"
+	"github.com/willfantom/neat/testbeds/mtv/mnapi/mnapitest"
+	"github.com/willfantom/neat/types"
+)
+
+//apiTestbed gives a testbed using a fake api through its api url, so no container is needed
+func apiTestbed(t *testing.T) (*testbeds.Testbed, *mnapitest.Server) {
+	server := mnapitest.NewServer(mnapitest.Topology{
+		Nodes: []mnapi.NodeInfo{
+			{Name: "h1", Class: "Host"},
+			{Name: "h2", Class: "Host"},
+			{Name: "s1", Class: "OVSSwitch"},
+		},
+		Links: []mnapi.LinkInfo{
+			{Node1: "h1", Node2: "s1", Interface1: "h1-eth0", Interface2: "s1-eth1"},
+			{Node1: "s1", Node2: "h2", Interface1: "s1-eth2", Interface2: "h2-eth0"},
+		},
+	})
+	t.Cleanup(server.Close)
+	testbed := &testbeds.Testbed{
+		Name:        "mtv-api-" + t.Name(),
+		VariantName: "mtv",
+		VariantConfig: map[string]interface{}{
+			"api": map[string]interface{}{
+				"url": server.URL(),
+			},
+		},
+	}
+	return testbed, server
+}
+
+func TestPingWithoutContainer(t *testing.T) {
+	testbed, server := apiTestbed(t)
+	response, err := doPing(context.Background(), testbed, types.PingRequest{Sender: "h1", Target: "h2"})
+	if err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	if response.Sent != 1 || response.Received != 1 {
+		t.Errorf("unexpected ping response: %+v", response)
+	}
+
+	if err := mnapiClient(t, server).SetLinkStatus("h1", "s1", false); err != nil {
+		t.Fatal(err)
+	}
+	response, err = doPing(context.Background(), testbed, types.PingRequest{Sender: "h1", Target: "h2"})
+	if err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	if response.Received != 0 {
+		t.Errorf("ping should not be received with the link down: %+v", response)
+	}
+}
+
+func TestSetLinkRestores(t *testing.T) {
+	testbed, server := apiTestbed(t)
+	delay := "20ms"
+	previous, err := doSetLink(testbed, types.LinkRequest{Node1: "s1", Node2: "h1", State: types.LinkStateDown, Delay: &delay})
+	if err != nil {
+		t.Fatalf("failed to set link: %v", err)
+	}
+	if !previous.Up || previous.Params.Delay != "" {
+		t.Errorf("unexpected previous link state: %+v", previous)
+	}
+	if link, _ := server.Link("h1", "s1"); link.Status != mnapi.LinkDown || link.Params.Delay != delay {
+		t.Errorf("link was not changed: %+v", link)
+	}
+
+	if _, err := doSetLink(testbed, previous.Restore()); err != nil {
+		t.Fatalf("failed to restore link: %v", err)
+	}
+	if link, _ := server.Link("h1", "s1"); link.Status != mnapi.LinkUp || link.Params.Delay != "" {
+		t.Errorf("link was not restored: %+v", link)
+	}
+}
+
+func TestNodeExecWithoutContainer(t *testing.T) {
+	testbed, server := apiTestbed(t)
+	server.SetExecHandler(func(node string, command []string) mnapi.ExecResult {
+		return mnapi.ExecResult{Stdout: node}
+	})
+	response, err := doExec(context.Background(), testbed, types.ExecRequest{Node: "h2", Command: []string{"hostname"}, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("failed to exec: %v", err)
+	}
+	if response.Stdout != "h2" {
+		t.Errorf("command ran on the wrong node: %+v", response)
+	}
+	if _, err := doExec(context.Background(), testbed, types.ExecRequest{Command: []string{"hostname"}}); err == nil {
+		t.Errorf("expected container exec to fail without a container")
+	}
+}
+
+func TestNodeExecCancelled(t *testing.T) {
+	testbed, server := apiTestbed(t)
+	release := make(chan struct{})
+	server.SetExecHandler(func(node string, command []string) mnapi.ExecResult {
+		<-release
+		return mnapi.ExecResult{}
+	})
+	defer close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := doExec(ctx, testbed, types.ExecRequest{Node: "h1", Command: []string{"sleep", "infinity"}}); err == nil {
+		t.Errorf("expected an error once cancelled")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("cancelling did not stop the exec")
+	}
+}
+
+func TestTopology(t *testing.T) {
+	testbed, _ := apiTestbed(t)
+	topology, err := doTopology(testbed)
+	if err != nil {
+		t.Fatalf("failed to get topology: %v", err)
+	}
+	if len(topology.Nodes) != 3 || len(topology.Links) != 2 {
+		t.Errorf("unexpected topology: %+v", topology)
+	}
+}
+
+func TestReadyFromEvents(t *testing.T) {
+	testbed, server := apiTestbed(t)
+	server.SetReady(false)
+	client := mnapiClient(t, server)
+
+	ready := make(chan error, 1)
+	go func() {
+		ready <- waitForReady(context.Background(), testbed, client)
+	}()
+	select {
+	case err := <-ready:
+		t.Fatalf("testbed was ready before the topology started: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	server.SetReady(true)
+	select {
+	case err := <-ready:
+		if err != nil {
+			t.Fatalf("failed to wait for the topology: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("testbed did not become ready")
+	}
+
+	if err := client.SetLinkStatus("h1", "s1", false); err != nil {
+		t.Fatal(err)
+	}
+	//give the link event time to arrive before the stream is stopped
+	time.Sleep(50 * time.Millisecond)
+	stopEvents(testbed)
+	server.Emit(mnapi.Event{Type: mnapi.EventNodeDown, Node: "h1"})
+	time.Sleep(50 * time.Millisecond)
+
+	events := testbed.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected the ready and link events only, got %+v", events)
+	}
+	if events[0].Type != mnapi.EventReady || events[1].Type != mnapi.EventLinkDown || events[1].Subject != "h1-s1" {
+		t.Errorf("unexpected timeline: %+v", events)
+	}
+}
+
+func TestReadyWithoutEvents(t *testing.T) {
+	testbed, server := apiTestbed(t)
+	server.Fail(mnapitest.RouteEvents, http.StatusNotFound, "not found", 0)
+	server.Fail(mnapitest.RouteNodes, http.StatusServiceUnavailable, "not ready", 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := waitForReady(ctx, testbed, mnapiClient(t, server)); err != nil {
+		t.Fatalf("failed to fall back to polling: %v", err)
+	}
+	if len(testbed.Events()) != 0 {
+		t.Errorf("no events should be recorded without an event stream")
+	}
+}
+
+func TestReadyTimeout(t *testing.T) {
+	testbed, server := apiTestbed(t)
+	testbed.VariantConfig["api"].(map[string]interface{})["ready_timeout"] = "200ms"
+	server.Fail(mnapitest.RouteEvents, http.StatusServiceUnavailable, "still starting", 0)
+	err := waitForReady(context.Background(), testbed, mnapiClient(t, server))
+	if err == nil || !strings.Contains(err.Error(), "within 200ms") || !strings.Contains(err.Error(), "still starting") {
+		t.Errorf("expected a timeout reporting the last error, got %v", err)
+	}
+
+	server.Recover(mnapitest.RouteEvents)
+	client, err := mnapi.NewClient(strings.Replace(server.URL(), "http://", "https://", 1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := waitForReady(context.Background(), testbed, client); err == nil {
+		t.Errorf("expected a tls failure to be reported")
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("a tls failure should not be retried")
+	}
+}
+
+func mnapiClient(t *testing.T, server *mnapitest.Server) *mnapi.Client {
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
@@ -0,0 +1,70 @@
+package mtv
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/tools/docker"
+)
+
+type runNetwork struct {
+	id    string
+	users int
+}
+
+var (
+	networkLock sync.Mutex
+	//networks are keyed by the engine's daemon address, as engines requested through different endpoints
+	//may be the same daemon
+	runNetworks = make(map[string]*runNetwork)
+)
+
+//acquireNetwork gives the network a testbed's container should use, creating the run's own network
+//on the engine the first time it is needed
+func acquireNetwork(engine *docker.Engine, parsedConfig *Config) (string, error) {
+	if parsedConfig.Network != "" {
+		if parsedConfig.Network != docker.NetworkHost && !engine.NetworkExists(parsedConfig.Network) {
+			return "", fmt.Errorf("docker network '%s' does not exist", parsedConfig.Network)
+		}
+		return parsedConfig.Network, nil
+	}
+	networkLock.Lock()
+	defer networkLock.Unlock()
+	network, ok := runNetworks[engine.Host]
+	if !ok {
+		id, err := engine.CreateNetwork(runNetworkName(), map[string]string{
+			"run": testbeds.RunID(),
+		})
+		if err != nil {
+			return "", err
+		}
+		network = &runNetwork{id: id}
+		runNetworks[engine.Host] = network
+	}
+	network.users++
+	return runNetworkName(), nil
+}
+
+//releaseNetwork removes the run's network from the engine once no testbed containers are using it
+func releaseNetwork(engine *docker.Engine, name string) error {
+	if name != runNetworkName() {
+		return nil
+	}
+	networkLock.Lock()
+	defer networkLock.Unlock()
+	network, ok := runNetworks[engine.Host]
+	if !ok {
+		return nil
+	}
+	network.users--
+	if network.users > 0 {
+		return nil
+	}
+	delete(runNetworks, engine.Host)
+	return engine.RemoveNetwork(network.id)
+}
+
+func runNetworkName() string {
+	return "neat-" + testbeds.RunID()
+}
@@ -1,34 +1,63 @@
 package mtv
 
 import (
-	"strings"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
-	"github.com/sirupsen/logrus"
 	"github.com/willfantom/neat/testbeds"
-	"github.com/willfantom/neat/tools"
-	"github.com/willfantom/neat/tools/docker"
+	"github.com/willfantom/neat/testbeds/mtv/mnapi"
+	"github.com/willfantom/neat/tools/script"
 )
 
 type Config struct {
-	Image   string `mapstructure:"image"`
-	Libvirt bool   `mapstructure:"libvirt"`
-	Files   string `mapstructure:"files"`
-	Command string `mapstructure:"command"`
+	Image       string            `mapstructure:"image"`
+	PullPolicy  string            `mapstructure:"pull_policy"`
+	Libvirt     bool              `mapstructure:"libvirt"`
+	Files       string            `mapstructure:"files"`
+	Topology    string            `mapstructure:"topology"`
+	Volumes     map[string]string `mapstructure:"volumes"`
+	Environment map[string]string `mapstructure:"environment"`
+	Command     []string          `mapstructure:"command"`
+	Logs        string            `mapstructure:"logs"`
+	Network     string            `mapstructure:"network"`
+	EngineHost  string            `mapstructure:"engine_host"`
+	API         APIConfig         `mapstructure:"api"`
+	Controller  *ControllerConfig `mapstructure:"controller"`
+}
+
+//APIConfig sets how neat connects to the mtv api, by default plain http to the container's address,
+//the ready timeout bounds how long neat waits for the api and topology to start
+type APIConfig struct {
+	URL          string            `mapstructure:"url"`
+	Scheme       string            `mapstructure:"scheme"`
+	Port         int               `mapstructure:"port"`
+	Token        string            `mapstructure:"token"`
+	TokenFile    string            `mapstructure:"token_file"`
+	Headers      map[string]string `mapstructure:"headers"`
+	ReadyTimeout time.Duration     `mapstructure:"ready_timeout"`
+
+	mnapi.TLSConfig `mapstructure:",squash"`
 }
 
 const (
-	dockerImage string = "ghcr.io/ng-cdi/mtv:test"
+	dockerImage     string = "ghcr.io/ng-cdi/mtv:test"
+	defaultTopology string = "topology.py"
+	filesMount      string = "/mnt"
+	defaultAPIPort  int    = 8080
+
+	defaultAPIReadyTimeout time.Duration = 5 * time.Minute
+
+	logsAlways    string = "always"
+	logsOnFailure string = "on-failure"
+	logsNever     string = "never"
 )
 
 var variant = testbeds.Variant{
 	Name:        "MTV",
 	Description: "A mininet fork designed for VNF testing",
 
-	Tools: []tools.Tool{
-		docker.Tool,
-	},
-
+	//the container engine is not a tool of the variant as each testbed may use its own,
+	//it is checked when the testbed's configuration is validated instead
 	ValidateConfiguration: validateConfiguration,
 	Create:                create,
 	Start:                 start,
@@ -37,26 +66,57 @@ var variant = testbeds.Variant{
 
 	HookArguments: getArguments,
 
-	DoPing: doPing,
+	Operations: testbeds.Operations{
+		testbeds.CapabilityPing:     testbeds.PingOperation(doPing),
+		testbeds.CapabilityExec:     testbeds.ExecOperation(doExec),
+		testbeds.CapabilityLinks:    testbeds.LinksOperation(doLinks),
+		testbeds.CapabilityFault:    testbeds.FaultOperation(doSetLink),
+		testbeds.CapabilityTopology: testbeds.TopologyOperation(doTopology),
+		testbeds.CapabilityCapture:  testbeds.CaptureOperation(startCapture),
+		testbeds.CapabilityVNF:      testbeds.VNFOperation(doVNF),
+	},
 }
 
 func parseConfig(config map[string]interface{}) (*Config, error) {
 	var parsedConfig Config
-	if err := mapstructure.Decode(config, &parsedConfig); err != nil {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			script.StringToArgsHookFunc(),
+		),
+		Result: &parsedConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(config); err != nil {
 		return nil, err
 	}
+	if parsedConfig.Topology == "" {
+		parsedConfig.Topology = defaultTopology
+	}
+	if parsedConfig.API.Scheme == "" {
+		parsedConfig.API.Scheme = "http"
+	}
+	if parsedConfig.API.Port == 0 {
+		parsedConfig.API.Port = defaultAPIPort
+	}
+	if parsedConfig.API.ReadyTimeout == 0 {
+		parsedConfig.API.ReadyTimeout = defaultAPIReadyTimeout
+	}
+	if parsedConfig.Controller != nil {
+		if parsedConfig.Controller.Port == 0 {
+			parsedConfig.Controller.Port = defaultControllerPort
+		}
+		if parsedConfig.Controller.ConnectTimeout == 0 {
+			parsedConfig.Controller.ConnectTimeout = defaultControllerConnectTimeout
+		}
+	}
 	return &parsedConfig, nil
 }
 
 func init() {
-	for _, tool := range variant.Tools {
-		if !tool.Check() {
-			logrus.WithFields(logrus.Fields{
-				"variant": strings.ToLower(variant.Name),
-				"tool":    strings.ToLower(tool.Name),
-			}).Warnln("testbed variant unavailable due to failed due to tool dependency check failure")
-			return
-		}
+	if err := testbeds.Register("mtv", variant); err != nil {
+		panic(err)
 	}
-	testbeds.Variants["mtv"] = variant
 }
@@ -0,0 +1,53 @@
+package mtv
+
+import (
+	"fmt"
+
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/testbeds/mtv/mnapi"
+	"github.com/willfantom/neat/types"
+)
+
+func doVNF(testbed *testbeds.Testbed, request types.VNFRequest) ([]types.VNF, error) {
+	parsedConfig, err := parseConfig(testbed.VariantConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !parsedConfig.Libvirt {
+		return nil, fmt.Errorf("mtv testbed '%s' does not have libvirt enabled so has no vnfs", testbed.Name)
+	}
+	client, err := apiClient(testbed)
+	if err != nil {
+		return nil, err
+	}
+	var vnfInfo []*mnapi.VNFInfo
+	switch {
+	case request.Name == "":
+		vnfInfo, err = client.GetVNFs()
+	case request.Action == "":
+		var vnf *mnapi.VNFInfo
+		vnf, err = client.GetVNF(request.Name)
+		vnfInfo = []*mnapi.VNFInfo{vnf}
+	default:
+		var vnf *mnapi.VNFInfo
+		vnf, err = client.ControlVNF(request.Name, request.Action)
+		vnfInfo = []*mnapi.VNFInfo{vnf}
+	}
+	if err != nil {
+		return nil, err
+	}
+	vnfs := make([]types.VNF, 0, len(vnfInfo))
+	for _, info := range vnfInfo {
+		if info == nil {
+			continue
+		}
+		vnfs = append(vnfs, types.VNF{
+			Name:   info.Name,
+			Domain: info.Domain,
+			State:  info.State,
+			Booted: info.Booted,
+			IPs:    info.IPs,
+		})
+	}
+	return vnfs, nil
+}
@@ -0,0 +1,68 @@
+package testbeds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+//SetDir sets the directory relative paths in the testbed's configuration are resolved against,
+//this is normally the directory containing the compose file
+func (testbed *Testbed) SetDir(dir string) {
+	testbed.dir = dir
+}
+
+func (testbed *Testbed) Dir() string {
+	if testbed.dir == "" {
+		dir, _ := os.Getwd()
+		return dir
+	}
+	return testbed.dir
+}
+
+//ResolvePath makes a path from the testbed's configuration absolute
+func (testbed *Testbed) ResolvePath(path string) string {
+	return ResolvePath(testbed.Dir(), path)
+}
+
+//ResolvePath makes the given path absolute using dir if it is relative
+func ResolvePath(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+//CheckPath gives an error naming the configuration field if the path does not exist
+func CheckPath(field string, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s '%s' does not exist", field, path)
+		}
+		return fmt.Errorf("%s '%s' can not be accessed: %w", field, path, err)
+	}
+	return nil
+}
+
+//resolveHooks makes all hook script paths absolute and checks that they exist
+func (testbed *Testbed) resolveHooks() error {
+	hooks := []struct {
+		field string
+		path  *string
+	}{
+		{"pre_start_script", &testbed.PreStartScript},
+		{"post_start_script", &testbed.PostStartScript},
+		{"pre_stop_script", &testbed.PreStopScript},
+		{"post_stop_script", &testbed.PostStopScript},
+	}
+	for _, hook := range hooks {
+		if *hook.path == "" {
+			continue
+		}
+		*hook.path = testbed.ResolvePath(*hook.path)
+		if err := CheckPath(hook.field, *hook.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/types"
+)
+
+const (
+	executablePrefix string = "neat-testbed-"
+)
+
+var log *logrus.Entry = logrus.WithField("component", "plugin")
+
+//Plugin is an external executable that provides a testbed variant
+type Plugin struct {
+	Name string
+	Path string
+
+	stateLock sync.Mutex
+	state     map[string]json.RawMessage
+}
+
+//Discover registers a testbed variant for every plugin executable found in the given directory,
+//plugin executables must be named neat-testbed-<variant>
+func Discover(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.WithField("dir", dir).Traceln("plugin directory does not exist")
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), executablePrefix) || entry.Mode()&0111 == 0 {
+			continue
+		}
+		plugin := &Plugin{
+			Name:  strings.TrimPrefix(entry.Name(), executablePrefix),
+			Path:  filepath.Join(dir, entry.Name()),
+			state: make(map[string]json.RawMessage),
+		}
+		if testbeds.VariantExists(plugin.Name) {
+			log.WithField("variant", plugin.Name).Warnln("plugin ignored as testbed variant already exists")
+			continue
+		}
+		variant, err := plugin.Variant()
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"variant":  plugin.Name,
+				"extended": err.Error(),
+			}).Warnln("plugin ignored as it could not be described")
+			continue
+		}
+		if err := testbeds.Register(plugin.Name, *variant); err != nil {
+			log.WithFields(logrus.Fields{
+				"variant":  plugin.Name,
+				"extended": err.Error(),
+			}).Warnln("plugin ignored as it could not be registered")
+			continue
+		}
+		log.WithField("variant", plugin.Name).Debugln("registered testbed variant plugin")
+	}
+	return nil
+}
+
+//Variant describes the plugin and builds a testbed variant that calls out to it
+func (p *Plugin) Variant() (*testbeds.Variant, error) {
+	response, err := p.call(context.Background(), &Request{Operation: operationDescribe})
+	if err != nil {
+		return nil, err
+	}
+	name := response.Name
+	if name == "" {
+		name = p.Name
+	}
+	operations := testbeds.Operations{}
+	for _, capability := range response.Capabilities {
+		switch capability {
+		case testbeds.CapabilityPing:
+			operations[capability] = testbeds.PingOperation(p.doPing)
+		case testbeds.CapabilityExec:
+			operations[capability] = testbeds.ExecOperation(p.doExec)
+		case testbeds.CapabilityLinks:
+			operations[capability] = testbeds.LinksOperation(p.doLinks)
+		case testbeds.CapabilityFault:
+			operations[capability] = testbeds.FaultOperation(p.doSetLink)
+		case testbeds.CapabilityTopology:
+			operations[capability] = testbeds.TopologyOperation(p.doTopology)
+		case testbeds.CapabilityCapture:
+			operations[capability] = testbeds.CaptureOperation(p.startCapture)
+		case testbeds.CapabilityVNF:
+			operations[capability] = testbeds.VNFOperation(p.doVNF)
+		default:
+			log.WithFields(logrus.Fields{
+				"variant":    p.Name,
+				"capability": capability,
+			}).Warnln("plugin capability is not supported by the plugin protocol")
+		}
+	}
+	return &testbeds.Variant{
+		Name:        name,
+		Description: response.Description,
+
+		ValidateConfiguration: p.validateConfiguration,
+		Create:                p.lifecycle(operationCreate),
+		Start:                 p.lifecycle(operationStart),
+		Stop:                  p.lifecycle(operationStop),
+		Remove:                p.lifecycle(operationRemove),
+
+		HookArguments: p.hookArguments,
+
+		Operations: operations,
+	}, nil
+}
+
+func (p *Plugin) validateConfiguration(testbed *testbeds.Testbed) (bool, error) {
+	response, err := p.call(context.Background(), &Request{
+		Operation: operationValidate,
+		Testbed:   testbedInfo(testbed),
+	})
+	if err != nil {
+		return false, err
+	}
+	if response.Valid == nil {
+		return false, fmt.Errorf("plugin '%s' gave no valid field in its response to %s", p.Name, operationValidate)
+	}
+	return *response.Valid, nil
+}
+
+func (p *Plugin) lifecycle(operation string) func(ctx context.Context, testbed *testbeds.Testbed) error {
+	return func(ctx context.Context, testbed *testbeds.Testbed) error {
+		_, err := p.callForTestbed(ctx, testbed, &Request{Operation: operation})
+		return err
+	}
+}
+
+func (p *Plugin) hookArguments(path string, testbed *testbeds.Testbed) []string {
+	response, err := p.callForTestbed(context.Background(), testbed, &Request{Operation: operationHookArguments, Path: path})
+	if err != nil {
+		log.WithField("variant", p.Name).Warnln(err.Error())
+		return []string{path}
+	}
+	if len(response.Arguments) == 0 {
+		return []string{path}
+	}
+	return response.Arguments
+}
+
+func (p *Plugin) doPing(ctx context.Context, testbed *testbeds.Testbed, request types.PingRequest) (*types.PingResponse, error) {
+	response, err := p.callForTestbed(ctx, testbed, &Request{Operation: operationPing, Ping: &request})
+	if err != nil {
+		return nil, err
+	}
+	if response.Ping == nil {
+		return nil, fmt.Errorf("plugin '%s' gave no ping response", p.Name)
+	}
+	return response.Ping, nil
+}
+
+func (p *Plugin) doExec(ctx context.Context, testbed *testbeds.Testbed, request types.ExecRequest) (*types.ExecResponse, error) {
+	response, err := p.callForTestbed(ctx, testbed, &Request{Operation: operationExec, Exec: &request})
+	if err != nil {
+		return nil, err
+	}
+	if response.Exec == nil {
+		return nil, fmt.Errorf("plugin '%s' gave no exec response", p.Name)
+	}
+	return response.Exec, nil
+}
+
+func (p *Plugin) doLinks(testbed *testbeds.Testbed) ([]types.Link, error) {
+	response, err := p.callForTestbed(context.Background(), testbed, &Request{Operation: operationLinks})
+	if err != nil {
+		return nil, err
+	}
+	return response.Links, nil
+}
+
+func (p *Plugin) doSetLink(testbed *testbeds.Testbed, request types.LinkRequest) (*types.Link, error) {
+	response, err := p.callForTestbed(context.Background(), testbed, &Request{Operation: operationLink, Link: &request})
+	if err != nil {
+		return nil, err
+	}
+	if response.Link == nil {
+		return nil, fmt.Errorf("plugin '%s' gave no previous link state", p.Name)
+	}
+	return response.Link, nil
+}
+
+func (p *Plugin) doTopology(testbed *testbeds.Testbed) (*types.Topology, error) {
+	response, err := p.callForTestbed(context.Background(), testbed, &Request{Operation: operationTopology})
+	if err != nil {
+		return nil, err
+	}
+	if response.Topology == nil {
+		return nil, fmt.Errorf("plugin '%s' gave no topology", p.Name)
+	}
+	return response.Topology, nil
+}
+
+func (p *Plugin) startCapture(testbed *testbeds.Testbed, request types.CaptureRequest) (testbeds.StopCapture, error) {
+	response, err := p.callForTestbed(context.Background(), testbed, &Request{Operation: operationCaptureStart, Capture: &request})
+	if err != nil {
+		return nil, err
+	}
+	captureID := response.CaptureID
+	return func(dir string) ([]string, error) {
+		if dir != "" {
+			if absDir, err := filepath.Abs(dir); err == nil {
+				dir = absDir
+			}
+		}
+		response, err := p.callForTestbed(context.Background(), testbed, &Request{Operation: operationCaptureStop, CaptureID: captureID, Dir: dir})
+		if err != nil {
+			return nil, err
+		}
+		return response.Artifacts, nil
+	}, nil
+}
+
+func (p *Plugin) doVNF(testbed *testbeds.Testbed, request types.VNFRequest) ([]types.VNF, error) {
+	response, err := p.callForTestbed(context.Background(), testbed, &Request{Operation: operationVNF, VNF: &request})
+	if err != nil {
+		return nil, err
+	}
+	return response.VNFs, nil
+}
+
+//callForTestbed sends the request along with the testbed and the state the plugin last returned
+//for it, any new state in the response replaces the stored state
+func (p *Plugin) callForTestbed(ctx context.Context, testbed *testbeds.Testbed, request *Request) (*Response, error) {
+	request.Testbed = testbedInfo(testbed)
+	p.stateLock.Lock()
+	request.State = p.state[testbed.Name]
+	p.stateLock.Unlock()
+
+	response, err := p.call(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.State) > 0 {
+		p.stateLock.Lock()
+		p.state[testbed.Name] = response.State
+		p.stateLock.Unlock()
+	}
+	return response, nil
+}
+
+func (p *Plugin) call(ctx context.Context, request *Request) (*Response, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin '%s' could not be run: %w", p.Name, err)
+	}
+	//the plugin may leave children holding its output open, so do not wait on them once cancelled
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	var runErr error
+	select {
+	case runErr = <-done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("plugin '%s' cancelled during %s: %w", p.Name, request.Operation, ctx.Err())
+	}
+	if stderr.Len() > 0 {
+		log.WithFields(logrus.Fields{
+			"variant":   p.Name,
+			"operation": request.Operation,
+		}).Debugln(strings.TrimSpace(stderr.String()))
+	}
+
+	var response Response
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("plugin '%s' failed during %s: %w", p.Name, request.Operation, runErr)
+		}
+		return nil, fmt.Errorf("plugin '%s' gave an invalid response to %s: %w", p.Name, request.Operation, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("plugin '%s' failed during %s: %s", p.Name, request.Operation, response.Error)
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("plugin '%s' failed during %s: %w", p.Name, request.Operation, runErr)
+	}
+	return &response, nil
+}
+
+func testbedInfo(testbed *testbeds.Testbed) *TestbedInfo {
+	return &TestbedInfo{
+		Name:    testbed.Name,
+		Variant: testbed.VariantName,
+		Dir:     testbed.Dir(),
+		Config:  testbed.VariantConfig,
+	}
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/types"
+)
+
+//script is a plugin whose behaviour is picked by the mode in the testbed's config, each lifecycle step
+//only succeeds if it is sent the state given by the step before it
+const script = `#!/bin/sh
+req=$(cat)
+case "$req" in
+  *'"mode":"fail"'*) echo '{"error":"boom"}'; exit 0;;
+  *'"mode":"crash"'*) echo 'exploded' >&2; exit 3;;
+  *'"mode":"malformed"'*) echo 'not json'; exit 0;;
+  *'"mode":"hang"'*) exec sleep 10;;
+esac
+case "$req" in
+  *'"operation":"describe"'*) echo '{"name":"Script","description":"a test plugin","capabilities":["ping","exec","teleport"]}';;
+  *'"operation":"validate"'*) case "$req" in *'"mode":"silent"'*) echo '{}';; *) echo '{"valid":true}';; esac;;
+  *'"operation":"create"'*) case "$req" in *'"state"'*) echo '{"error":"unexpected state"}';; *) echo '{"state":{"step":1}}';; esac;;
+  *'"operation":"start"'*) case "$req" in *'"state":{"step":1}'*) echo '{"state":{"step":2}}';; *) echo '{"error":"not created"}';; esac;;
+  *'"operation":"stop"'*) case "$req" in *'"state":{"step":2}'*) echo '{"state":{"step":3}}';; *) echo '{"error":"not started"}';; esac;;
+  *'"operation":"remove"'*) case "$req" in *'"state":{"step":3}'*) echo '{}';; *) echo '{"error":"not stopped"}';; esac;;
+  *'"operation":"ping"'*) echo '{"ping":{"sent":3,"received":2}}';;
+  *) echo '{"error":"unknown operation"}';;
+esac
+`
+
+//newPlugin writes the script plugin into its own directory, giving the directory and the plugin's variant name
+func newPlugin(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	//variants are registered globally so each run needs its own name
+	name := fmt.Sprintf("script%d", time.Now().UnixNano())
+	if err := ioutil.WriteFile(filepath.Join(dir, executablePrefix+name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir, name
+}
+
+func scriptPlugin(t *testing.T) *Plugin {
+	dir, name := newPlugin(t)
+	return &Plugin{
+		Name:  name,
+		Path:  filepath.Join(dir, executablePrefix+name),
+		state: make(map[string]json.RawMessage),
+	}
+}
+
+func scriptTestbed(mode string) *testbeds.Testbed {
+	return &testbeds.Testbed{
+		Name:          "plugin-test",
+		VariantConfig: map[string]interface{}{"mode": mode},
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir, name := newPlugin(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, executablePrefix+"notrun"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Discover(dir); err != nil {
+		t.Fatalf("failed to discover plugins: %v", err)
+	}
+	defer delete(testbeds.Variants, name)
+	if testbeds.VariantExists("notrun") {
+		t.Errorf("a plugin that is not executable should not be registered")
+	}
+	variant, ok := testbeds.Variants[name]
+	if !ok {
+		t.Fatalf("plugin was not registered")
+	}
+	if variant.Name != "Script" || variant.Description != "a test plugin" {
+		t.Errorf("unexpected description: %+v", variant)
+	}
+	if !variant.Supports(testbeds.CapabilityPing) || !variant.Supports(testbeds.CapabilityExec) || len(variant.Capabilities()) != 2 {
+		t.Errorf("unexpected capabilities: %v", variant.Capabilities())
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	plugin := scriptPlugin(t)
+	testbed := scriptTestbed("")
+	for _, operation := range []string{operationCreate, operationStart, operationStop, operationRemove} {
+		if err := plugin.lifecycle(operation)(context.Background(), testbed); err != nil {
+			t.Fatalf("failed to %s: %v", operation, err)
+		}
+	}
+	//a response without state leaves the last state in place
+	if state := string(plugin.state[testbed.Name]); state != `{"step":3}` {
+		t.Errorf("unexpected stored state %s", state)
+	}
+
+	response, err := plugin.doPing(context.Background(), testbed, types.PingRequest{Sender: "h1", Target: "h2"})
+	if err != nil || response.Sent != 3 || response.Received != 2 {
+		t.Errorf("unexpected ping response %+v: %v", response, err)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	plugin := scriptPlugin(t)
+	expected := map[string]string{
+		"fail":      "failed during create: boom",
+		"crash":     "failed during create: exit status 3",
+		"malformed": "gave an invalid response to create",
+	}
+	for mode, message := range expected {
+		err := plugin.lifecycle(operationCreate)(context.Background(), scriptTestbed(mode))
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("expected %s to fail with '%s', got %v", mode, message, err)
+		}
+	}
+	//an unknown operation is reported by the plugin rather than skipped
+	if _, err := plugin.doTopology(scriptTestbed("")); err == nil {
+		t.Errorf("expected an unknown operation to fail")
+	}
+}
+
+func TestValidateNeedsValidField(t *testing.T) {
+	plugin := scriptPlugin(t)
+	if valid, err := plugin.validateConfiguration(scriptTestbed("")); !valid || err != nil {
+		t.Errorf("expected a valid configuration, got %v", err)
+	}
+	valid, err := plugin.validateConfiguration(scriptTestbed("silent"))
+	if valid || err == nil || !strings.Contains(err.Error(), "no valid field") {
+		t.Errorf("expected a missing valid field to be reported, got %v", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	plugin := scriptPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := plugin.lifecycle(operationStart)(ctx, scriptTestbed("hang"))
+	if err == nil || !strings.Contains(err.Error(), "cancelled during start") {
+		t.Errorf("expected the start to be cancelled, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("cancelling did not stop the plugin")
+	}
+}
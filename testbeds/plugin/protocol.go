@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"encoding/json"
+
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/types"
+)
+
+const (
+	operationDescribe      string = "describe"
+	operationValidate      string = "validate"
+	operationCreate        string = "create"
+	operationStart         string = "start"
+	operationStop          string = "stop"
+	operationRemove        string = "remove"
+	operationHookArguments string = "hook_args"
+	operationPing          string = "ping"
+	operationExec          string = "exec"
+	operationLinks         string = "links"
+	operationLink          string = "link"
+	operationTopology      string = "topology"
+	operationCaptureStart  string = "capture_start"
+	operationCaptureStop   string = "capture_stop"
+	operationVNF           string = "vnf"
+)
+
+//Request is written as a single json document to the plugin's stdin
+type Request struct {
+	Operation string          `json:"operation"`
+	Testbed   *TestbedInfo    `json:"testbed,omitempty"`
+	State     json.RawMessage `json:"state,omitempty"`
+
+	Path string             `json:"path,omitempty"`
+	Ping *types.PingRequest `json:"ping,omitempty"`
+	Exec *types.ExecRequest `json:"exec,omitempty"`
+	Link *types.LinkRequest `json:"link,omitempty"`
+
+	Capture   *types.CaptureRequest `json:"capture,omitempty"`
+	CaptureID string                `json:"capture_id,omitempty"`
+	Dir       string                `json:"dir,omitempty"`
+
+	VNF *types.VNFRequest `json:"vnf,omitempty"`
+}
+
+//TestbedInfo is the subset of a testbed's specification that is shared with a plugin
+type TestbedInfo struct {
+	Name    string                 `json:"name"`
+	Variant string                 `json:"variant"`
+	Dir     string                 `json:"dir"`
+	Config  map[string]interface{} `json:"config"`
+}
+
+//Response is read as a single json document from the plugin's stdout,
+//valid is a pointer so that a validate response without it can be told apart from an invalid configuration
+type Response struct {
+	Error string          `json:"error,omitempty"`
+	State json.RawMessage `json:"state,omitempty"`
+
+	Name         string                `json:"name,omitempty"`
+	Description  string                `json:"description,omitempty"`
+	Capabilities []testbeds.Capability `json:"capabilities,omitempty"`
+	Valid        *bool                 `json:"valid,omitempty"`
+	Arguments    []string              `json:"arguments,omitempty"`
+	Ping         *types.PingResponse   `json:"ping,omitempty"`
+	Exec         *types.ExecResponse   `json:"exec,omitempty"`
+	Links        []types.Link          `json:"links,omitempty"`
+	Link         *types.Link           `json:"link,omitempty"`
+	Topology     *types.Topology       `json:"topology,omitempty"`
+	CaptureID    string                `json:"capture_id,omitempty"`
+	Artifacts    []string              `json:"artifacts,omitempty"`
+	VNFs         []types.VNF           `json:"vnfs,omitempty"`
+}
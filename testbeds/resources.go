@@ -0,0 +1,72 @@
+package testbeds
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/docker/go-units"
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+)
+
+//ResourceCap fixes the resources a testbed may use so results are comparable across runs and hosts,
+//a disabled cap applies no limits and stops the testbed inheriting a cap from its template
+type ResourceCap struct {
+	Disabled  bool   `mapstructure:"disabled" json:"disabled,omitempty"`
+	CPUQuota  int64  `mapstructure:"cpu_quota" json:"cpu_quota,omitempty"`
+	CPUPeriod int64  `mapstructure:"cpu_period" json:"cpu_period,omitempty"`
+	CPUSet    string `mapstructure:"cpuset" json:"cpuset,omitempty"`
+	Memory    string `mapstructure:"memory" json:"memory,omitempty"`
+	PidsLimit int64  `mapstructure:"pids_limit" json:"pids_limit,omitempty"`
+}
+
+//Applies is true if the resource cap is given and not disabled
+func (rc *ResourceCap) Applies() bool {
+	return rc != nil && !rc.Disabled
+}
+
+func (rc *ResourceCap) Validate() error {
+	if rc.Disabled && *rc != (ResourceCap{Disabled: true}) {
+		return fmt.Errorf("a disabled resource cap can not set any limits")
+	}
+	if rc.CPUQuota < 0 {
+		return fmt.Errorf("resource cap cpu_quota can not be negative")
+	}
+	if rc.CPUPeriod < 0 {
+		return fmt.Errorf("resource cap cpu_period can not be negative")
+	}
+	if rc.PidsLimit < 0 {
+		return fmt.Errorf("resource cap pids_limit can not be negative")
+	}
+	if _, err := rc.MemoryBytes(); err != nil {
+		return err
+	}
+	return nil
+}
+
+//MemoryBytes parses the memory limit which may be given with a unit suffix (e.g. 512m, 2g)
+func (rc *ResourceCap) MemoryBytes() (int64, error) {
+	if rc.Memory == "" {
+		return 0, nil
+	}
+	bytes, err := units.RAMInBytes(rc.Memory)
+	if err != nil {
+		return 0, fmt.Errorf("resource cap memory '%s' is not valid: %w", rc.Memory, err)
+	}
+	return bytes, nil
+}
+
+//ResourceCapHookFunc decodes the older boolean form of resource_cap, which never applied any limits,
+//false gives a disabled resource cap and true gives one with every limit left unset
+func ResourceCapHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.Bool || (to != reflect.TypeOf(ResourceCap{}) && to != reflect.TypeOf(&ResourceCap{})) {
+			return data, nil
+		}
+		if !data.(bool) {
+			return map[string]interface{}{"disabled": true}, nil
+		}
+		logrus.Warnln("resource_cap: true sets no limits, give cpu_quota, cpu_period, cpuset, memory or pids_limit instead")
+		return map[string]interface{}{}, nil
+	}
+}
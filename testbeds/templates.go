@@ -0,0 +1,115 @@
+package testbeds
+
+import (
+	"fmt"
+	"strings"
+)
+
+//ResolveTemplates expands the extends field of each given testbed using the given templates,
+//templates may themselves extend other templates
+func ResolveTemplates(templates map[string]*Testbed, allTestbeds []*Testbed) error {
+	normalized := make(map[string]*Testbed)
+	for name, template := range templates {
+		normalized[strings.ToLower(name)] = template
+	}
+	for _, testbed := range allTestbeds {
+		if err := testbed.resolve(normalized, []string{}); err != nil {
+			return fmt.Errorf("testbed '%s': %w", testbed.Name, err)
+		}
+	}
+	return nil
+}
+
+func (testbed *Testbed) resolve(templates map[string]*Testbed, seen []string) error {
+	if testbed.Extends == "" {
+		return nil
+	}
+	name := strings.ToLower(testbed.Extends)
+	for _, seenName := range seen {
+		if seenName == name {
+			return fmt.Errorf("template cycle detected: %s -> %s", strings.Join(seen, " -> "), name)
+		}
+	}
+	template, ok := templates[name]
+	if !ok {
+		return fmt.Errorf("template '%s' does not exist", testbed.Extends)
+	}
+	if err := template.resolve(templates, append(seen, name)); err != nil {
+		return err
+	}
+	testbed.extend(template)
+	testbed.Extends = ""
+	return nil
+}
+
+//extend fills the testbed with any values from the template that the testbed does not set itself,
+//variant configurations are deep merged with the testbed taking precedence
+func (testbed *Testbed) extend(template *Testbed) {
+	if testbed.VariantName == "" {
+		testbed.VariantName = template.VariantName
+	}
+	if testbed.ResourceCap == nil && template.ResourceCap != nil {
+		resourceCap := *template.ResourceCap
+		testbed.ResourceCap = &resourceCap
+	}
+	if testbed.SampleInterval == 0 {
+		testbed.SampleInterval = template.SampleInterval
+	}
+	hooks := []struct {
+		field    *string
+		template string
+	}{
+		{&testbed.PreStartScript, template.PreStartScript},
+		{&testbed.PreStart, template.PreStart},
+		{&testbed.PostStartScript, template.PostStartScript},
+		{&testbed.PostStart, template.PostStart},
+		{&testbed.PreStopScript, template.PreStopScript},
+		{&testbed.PreStop, template.PreStop},
+		{&testbed.PostStopScript, template.PostStopScript},
+		{&testbed.PostStop, template.PostStop},
+	}
+	for _, hook := range hooks {
+		if *hook.field == "" {
+			*hook.field = hook.template
+		}
+	}
+	testbed.VariantConfig = mergeConfig(template.VariantConfig, testbed.VariantConfig)
+}
+
+func mergeConfig(base, override map[string]interface{}) map[string]interface{} {
+	if base == nil && override == nil {
+		return nil
+	}
+	merged := make(map[string]interface{})
+	for key, value := range base {
+		if nested, ok := toStringMap(value); ok {
+			value = mergeConfig(nested, nil)
+		}
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseNested, baseIsMap := toStringMap(merged[key])
+		overrideNested, overrideIsMap := toStringMap(value)
+		if baseIsMap && overrideIsMap {
+			merged[key] = mergeConfig(baseNested, overrideNested)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return typed, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{})
+		for key, nested := range typed {
+			converted[fmt.Sprintf("%v", key)] = nested
+		}
+		return converted, true
+	default:
+		return nil, false
+	}
+}
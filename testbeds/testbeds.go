@@ -1,15 +1,20 @@
 package testbeds
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/willfantom/neat/tools/script"
 	"github.com/willfantom/neat/types"
 )
 
-var testbeds = make(map[string]*Testbed)
+var (
+	testbeds   = make(map[string]*Testbed)
+	eventsLock sync.Mutex
+)
 
 func GetTestbed(searchTerm string) (*Testbed, error) {
 	//TODO: maybe do some fuzzy search for names (not ids though)
@@ -28,10 +33,70 @@ func (testbed *Testbed) Validate() (bool, error) {
 	} else {
 		testbed.variant = Variants[testbed.VariantName]
 	}
+	if err := testbed.variant.Available(); err != nil {
+		return false, fmt.Errorf("testbed variant '%s' is unavailable: %w", testbed.VariantName, err)
+	}
+
+	if testbed.ResourceCap != nil {
+		if err := testbed.ResourceCap.Validate(); err != nil {
+			return false, err
+		}
+	}
+
+	if err := testbed.resolveHooks(); err != nil {
+		return false, err
+	}
+
+	if testbed.SampleInterval < 0 {
+		return false, fmt.Errorf("sample interval can not be negative")
+	}
+
+	if validConfig, err := testbed.variant.ValidateConfiguration(testbed); err != nil && !validConfig {
+		return false, err
+	} else if err == nil && !validConfig {
+		return false, fmt.Errorf("testbed variant specific configuration is not valid")
+	}
 
 	return true, nil
 }
 
+//MarkFailed records that the testbed either failed a lifecycle step or a test run against it
+func (testbed *Testbed) MarkFailed() {
+	testbed.failed = true
+}
+
+func (testbed *Testbed) Failed() bool {
+	return testbed.failed
+}
+
+//Created reports if the testbed has been created and not yet removed
+func (testbed *Testbed) Created() bool {
+	return testbed.created
+}
+
+//Started reports if the testbed has been started and not yet stopped
+func (testbed *Testbed) Started() bool {
+	return testbed.started
+}
+
+func (testbed *Testbed) AddArtifact(path string) {
+	testbed.Metrics.Artifacts = append(testbed.Metrics.Artifacts, path)
+}
+
+//RecordEvent adds the event to the testbed's timeline, it is safe to call while the testbed is running
+func (testbed *Testbed) RecordEvent(event types.Event) {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+	testbed.Metrics.Events = append(testbed.Metrics.Events, event)
+}
+
+//Events gives a copy of the testbed's timeline so far
+func (testbed *Testbed) Events() []types.Event {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+	return append([]types.Event{}, testbed.Metrics.Events...)
+}
+
 func (testbed *Testbed) Add() (string, error) {
 	if valid, err := testbed.Validate(); !valid {
 		return "", err
@@ -41,73 +106,96 @@ func (testbed *Testbed) Add() (string, error) {
 	return id, nil
 }
 
-func Create(id string) error {
+func Create(ctx context.Context, id string) error {
 	testbed, err := GetTestbed(id)
 	if err != nil {
 		return err
 	}
 	start := time.Now()
-	if err := testbed.variant.Create(testbed); err != nil {
+	if err := testbed.variant.Create(ctx, testbed); err != nil {
+		testbed.MarkFailed()
 		return err
 	}
+	testbed.created = true
 	testbed.Metrics.CreationTime = time.Since(start)
 	testbed.Metrics.CreatedAt = time.Now()
 	return nil
 }
 
-func Start(id string) error {
+func Start(ctx context.Context, id string) error {
 	testbed, err := GetTestbed(id)
 	if err != nil {
 		return err
 	}
 	if testbed.PreStartScript != "" {
-		if err := script.Run(testbed.variant.HookArguments(testbed.PreStartScript, testbed)...); err != nil {
+		if err := script.Run(ctx, testbed.variant.HookArguments(testbed.PreStartScript, testbed)...); err != nil {
 			return err
 		}
 	}
-	if err := testbed.variant.Start(testbed); err != nil {
+	start := time.Now()
+	runs := len(testbed.Metrics.Runs)
+	if err := testbed.variant.Start(ctx, testbed); err != nil {
+		testbed.MarkFailed()
 		return err
 	}
+	testbed.started = true
+	if len(testbed.Metrics.Runs) == runs {
+		testbed.Metrics.Runs = append(testbed.Metrics.Runs, RunMetrics{
+			StartedAt: time.Now(),
+			StartTime: time.Since(start),
+		})
+	}
 	if testbed.PostStartScript != "" {
-		if err := script.Run(testbed.variant.HookArguments(testbed.PostStartScript, testbed)...); err != nil {
+		if err := script.Run(ctx, testbed.variant.HookArguments(testbed.PostStartScript, testbed)...); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func Stop(id string) error {
+func Stop(ctx context.Context, id string) error {
 	testbed, err := GetTestbed(id)
 	if err != nil {
 		return err
 	}
 	if testbed.PreStopScript != "" {
-		if err := script.Run(testbed.variant.HookArguments(testbed.PreStopScript, testbed)...); err != nil {
+		if err := script.Run(ctx, testbed.variant.HookArguments(testbed.PreStopScript, testbed)...); err != nil {
 			return err
 		}
 	}
-	if err := testbed.variant.Stop(testbed); err != nil {
+	start := time.Now()
+	if err := testbed.variant.Stop(ctx, testbed); err != nil {
+		testbed.MarkFailed()
 		return err
 	}
+	testbed.started = false
+	if len(testbed.Metrics.Runs) > 0 {
+		if run := &testbed.Metrics.Runs[len(testbed.Metrics.Runs)-1]; run.StoppedAt.IsZero() {
+			run.StoppedAt = time.Now()
+			run.StopTime = time.Since(start)
+		}
+	}
 	if testbed.PostStopScript != "" {
-		if err := script.Run(testbed.variant.HookArguments(testbed.PostStopScript, testbed)...); err != nil {
+		if err := script.Run(ctx, testbed.variant.HookArguments(testbed.PostStopScript, testbed)...); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func Remove(id string) error {
+func Remove(ctx context.Context, id string) error {
 	testbed, err := GetTestbed(id)
 	if err != nil {
 		return err
 	}
-	if err := testbed.variant.Remove(testbed); err != nil {
+	start := time.Now()
+	if err := testbed.variant.Remove(ctx, testbed); err != nil {
 		return err
 	}
+	testbed.created = false
+	if testbed.Metrics.RemovedAt.IsZero() {
+		testbed.Metrics.RemovedAt = time.Now()
+		testbed.Metrics.RemoveTime = time.Since(start)
+	}
 	return nil
 }
-
-func (testbed *Testbed) DoPing(request types.PingRequest) (*types.PingResponse, error) {
-	return testbed.variant.DoPing(testbed, request)
-}
@@ -1,9 +1,11 @@
 package testbeds
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/sirupsen/logrus"
 	"github.com/willfantom/neat/tools"
-	"github.com/willfantom/neat/types"
 )
 
 type Variant struct {
@@ -12,15 +14,15 @@ type Variant struct {
 
 	Tools []tools.Tool
 
-	ValidateConfiguration func(config map[string]interface{}) (bool, error)
-	Create                func(testbed *Testbed) error
-	Start                 func(testbed *Testbed) error
-	Stop                  func(testbed *Testbed) error
-	Remove                func(testbed *Testbed) error
+	ValidateConfiguration func(testbed *Testbed) (bool, error)
+	Create                func(ctx context.Context, testbed *Testbed) error
+	Start                 func(ctx context.Context, testbed *Testbed) error
+	Stop                  func(ctx context.Context, testbed *Testbed) error
+	Remove                func(ctx context.Context, testbed *Testbed) error
 
 	HookArguments func(path string, testbed *Testbed) []string
 
-	DoPing func(testbed *Testbed, request types.PingRequest) (*types.PingResponse, error)
+	Operations Operations
 }
 
 func VariantExists(name string) bool {
@@ -29,4 +31,22 @@ func VariantExists(name string) bool {
 	return ok
 }
 
+//Register adds the variant under the given name, checking that each of its operations has
+//the type that matches its capability so that Supports agrees with the typed operations
+func Register(name string, variant Variant) error {
+	if VariantExists(name) {
+		return fmt.Errorf("testbed variant '%s' already exists", name)
+	}
+	if err := variant.Operations.validate(); err != nil {
+		return fmt.Errorf("testbed variant '%s' is not valid: %w", name, err)
+	}
+	Variants[name] = variant
+	return nil
+}
+
+//Available checks that all the tools the variant depends on can be used
+func (variant Variant) Available() error {
+	return tools.Require(variant.Tools...)
+}
+
 var Variants = map[string]Variant{}
@@ -0,0 +1,54 @@
+package exec
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fatih/structs"
+	"github.com/mitchellh/mapstructure"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/tools/script"
+	"github.com/willfantom/neat/types"
+)
+
+func ValidateConfiguration(config map[string]interface{}) (bool, error) {
+	execRequest, err := parseConfig(config)
+	if err != nil {
+		return false, err
+	}
+	if len(execRequest.Command) == 0 {
+		return false, errors.New("exec tests require a command")
+	}
+	return true, nil
+}
+
+func Run(ctx context.Context, testbed *testbeds.Testbed, config map[string]interface{}) (map[string]interface{}, error) {
+	execRequest, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := testbed.Exec(ctx, *execRequest)
+	if err != nil {
+		return nil, err
+	}
+	return structs.Map(result), nil
+}
+
+func parseConfig(config map[string]interface{}) (*types.ExecRequest, error) {
+	var execRequest types.ExecRequest
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			script.StringToArgsHookFunc(),
+		),
+		Result: &execRequest,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(config); err != nil {
+		return nil, err
+	}
+	return &execRequest, nil
+}
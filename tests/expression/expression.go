@@ -0,0 +1,25 @@
+package expression
+
+import (
+	"errors"
+
+	"github.com/antonmedv/expr"
+)
+
+//Evaluate runs the given expression against a test result, the expression must give a bool
+func Evaluate(result map[string]interface{}, expression string) (bool, error) {
+	program, err := expr.Compile(expression, expr.Env(result))
+	if err != nil {
+		return false, err
+	}
+	output, err := expr.Run(program, result)
+	if err != nil {
+		return false, err
+	}
+
+	if pass, ok := output.(bool); !ok {
+		return false, errors.New("expression did not evaluate to bool")
+	} else {
+		return pass, nil
+	}
+}
@@ -1,9 +1,8 @@
 package ping
 
 import (
-	"errors"
+	"context"
 
-	"github.com/antonmedv/expr"
 	"github.com/fatih/structs"
 	"github.com/mitchellh/mapstructure"
 	"github.com/willfantom/neat/testbeds"
@@ -19,32 +18,15 @@ func ValidateConfiguration(config map[string]interface{}) (bool, error) {
 	return true, nil
 }
 
-func Run(testbed *testbeds.Testbed, config map[string]interface{}) (map[string]interface{}, error) {
+func Run(ctx context.Context, testbed *testbeds.Testbed, config map[string]interface{}) (map[string]interface{}, error) {
 	var pingRequest types.PingRequest
 	if err := mapstructure.Decode(config, &pingRequest); err != nil {
 		return nil, err
 	}
 
-	result, err := testbed.DoPing(pingRequest)
+	result, err := testbed.DoPing(ctx, pingRequest)
 	if err != nil {
 		return nil, err
 	}
 	return structs.Map(result), nil
 }
-
-func Evaluate(result map[string]interface{}, expression string) (bool, error) {
-	program, err := expr.Compile(expression, expr.Env(result))
-	if err != nil {
-		return false, err
-	}
-	output, err := expr.Run(program, result)
-	if err != nil {
-		return false, err
-	}
-
-	if pass, ok := output.(bool); !ok {
-		return false, errors.New("expression did not evaluate to bool")
-	} else {
-		return pass, nil
-	}
-}
@@ -1,10 +1,15 @@
 package tests
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/sirupsen/logrus"
+	"github.com/willfantom/neat/artifacts"
 	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/types"
 )
 
 type Test struct {
@@ -13,6 +18,7 @@ type Test struct {
 	Variant string `mapstructure:"variant" json:"variant"`
 	variant Variant
 	Order   uint `mapstructure:"order" json:"order"`
+	//Repeats is how many times the test is run on each testbed, 0 runs it once
 	Repeats uint `mapstructure:"repeats" json:"repeats"`
 
 	TestbedNames []string `mapstructure:"testbeds" json:"testbeds"`
@@ -29,23 +35,50 @@ type Test struct {
 
 	VariantConfig map[string]interface{} `mapstructure:"config" json:"config"`
 
+	//Faults are applied to every testbed before the test runs on it and are reverted afterwards
+	Faults []types.LinkRequest `mapstructure:"faults" json:"faults,omitempty"`
+
+	//Capture records packets on every testbed while the test runs on it
+	Capture *Capture `mapstructure:"capture" json:"capture,omitempty"`
+
 	Metrics map[string]Metrics `mapstructure:"metrics" json:"metrics"`
+
+	dir string
 }
 
+//Metrics of a test on a testbed cover every repeat of the test
 type Metrics struct {
 	StartedAt     time.Time     `mapstructure:"started_at" json:"started_at"`
 	ExecutionTime time.Duration `mapstructure:"execution_time" json:"execution_time"`
+	Artifacts     []string      `mapstructure:"artifacts" json:"artifacts,omitempty"`
+}
+
+type Capture struct {
+	When                 string `mapstructure:"when" json:"when,omitempty"`
+	types.CaptureRequest `mapstructure:",squash"`
 }
 
+const (
+	captureAlways    string = "always"
+	captureOnFailure string = "on-failure"
+)
+
 func (test *Test) Validate() (bool, error) {
 	if !VariantExists(test.Variant) {
 		return false, fmt.Errorf("test variant '%s' does not exist", test.Variant)
 	}
 	test.variant = variants[test.Variant]
 
+	test.testbeds = make([]*testbeds.Testbed, 0, len(test.TestbedNames))
 	for _, testbedName := range test.TestbedNames {
 		if testbed, err := testbeds.GetTestbed(testbedName); err != nil {
 			return false, err
+		} else if err := testbed.Require(test.variant.Requires...); err != nil {
+			return false, fmt.Errorf("test '%s' (variant '%s') cannot run: %w", test.Name, test.Variant, err)
+		} else if err := test.requireFaults(testbed); err != nil {
+			return false, fmt.Errorf("test '%s' cannot run: %w", test.Name, err)
+		} else if err := test.requireCapture(testbed); err != nil {
+			return false, fmt.Errorf("test '%s' cannot run: %w", test.Name, err)
 		} else {
 			test.testbeds = append(test.testbeds, testbed)
 		}
@@ -59,6 +92,24 @@ func (test *Test) Validate() (bool, error) {
 	if test.Evaluate == "" && test.Expression == "" && test.EvaluationScript == "" {
 		return false, fmt.Errorf("no method to evaluate test provided")
 	}
+	if err := test.resolveScripts(); err != nil {
+		return false, err
+	}
+	for _, fault := range test.Faults {
+		if err := testbeds.ValidateLinkRequest(fault); err != nil {
+			return false, fmt.Errorf("test '%s' fault is not valid: %w", test.Name, err)
+		}
+	}
+	if test.Capture != nil {
+		switch test.Capture.When {
+		case "", captureAlways, captureOnFailure:
+		default:
+			return false, fmt.Errorf("test '%s' capture when '%s' is not valid (always or on-failure)", test.Name, test.Capture.When)
+		}
+		if err := testbeds.ValidateCaptureRequest(test.Capture.CaptureRequest); err != nil {
+			return false, fmt.Errorf("test '%s' capture is not valid: %w", test.Name, err)
+		}
+	}
 	// if test.Expression != "" {
 	// 	if validConfig, err := variants[test.Variant].ValidateExpression(test.Expression); err != nil && !validConfig {
 	// 		return false, err
@@ -69,31 +120,168 @@ func (test *Test) Validate() (bool, error) {
 	return true, nil
 }
 
+//SetDir sets the directory relative paths in the test's configuration are resolved against,
+//this is normally the directory containing the compose file
+func (test *Test) SetDir(dir string) {
+	test.dir = dir
+}
+
+//resolveScripts makes all script paths absolute and checks that they exist
+func (test *Test) resolveScripts() error {
+	scripts := []struct {
+		field string
+		path  *string
+	}{
+		{"eval_script", &test.EvaluationScript},
+		{"pre_run_script", &test.PreRunScript},
+		{"post_run_script", &test.PostRunScript},
+	}
+	for _, script := range scripts {
+		if *script.path == "" {
+			continue
+		}
+		*script.path = testbeds.ResolvePath(test.dir, *script.path)
+		if err := testbeds.CheckPath(script.field, *script.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (test *Test) requireFaults(testbed *testbeds.Testbed) error {
+	if len(test.Faults) == 0 {
+		return nil
+	}
+	return testbed.Require(testbeds.CapabilityFault)
+}
+
+func (test *Test) requireCapture(testbed *testbeds.Testbed) error {
+	if test.Capture == nil {
+		return nil
+	}
+	return testbed.Require(testbeds.CapabilityCapture)
+}
+
+func (test *Test) startCapture(testbed *testbeds.Testbed) (testbeds.StopCapture, error) {
+	if test.Capture == nil {
+		return nil, nil
+	}
+	return testbed.StartCapture(test.Capture.CaptureRequest)
+}
+
+//stopCapture stops the capture, keeping its pcap files as artifacts of the test unless the test passed
+//and the capture is only wanted on failure
+func (test *Test) stopCapture(testbed *testbeds.Testbed, iteration uint, stop testbeds.StopCapture, passed bool) {
+	if stop == nil {
+		return
+	}
+	logger := logrus.WithFields(logrus.Fields{
+		"test":    test.Name,
+		"testbed": testbed.Name,
+	})
+	dir := ""
+	if !passed || test.Capture.When == captureAlways {
+		var err error
+		if dir, err = artifacts.Dir("tests", test.Name, testbed.Name, strconv.FormatUint(uint64(iteration), 10)); err != nil {
+			logger.Warnln(err.Error())
+		}
+	}
+	saved, err := stop(dir)
+	if err != nil {
+		logger.Warnln("failed to save capture: " + err.Error())
+	}
+	metrics := test.Metrics[testbed.Name]
+	metrics.Artifacts = append(metrics.Artifacts, saved...)
+	test.Metrics[testbed.Name] = metrics
+}
+
+//applyFaults applies the test's faults to the testbed in order, giving a function that reverts them
+//in reverse order, if a fault can not be applied those already applied are reverted
+func (test *Test) applyFaults(testbed *testbeds.Testbed) (func(), error) {
+	previous := make([]*types.Link, 0, len(test.Faults))
+	restore := func() {
+		for idx := len(previous) - 1; idx >= 0; idx-- {
+			if _, err := testbed.SetLink(previous[idx].Restore()); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"test":    test.Name,
+					"testbed": testbed.Name,
+					"link":    fmt.Sprintf("%s-%s", previous[idx].Node1, previous[idx].Node2),
+				}).Warnln("failed to revert fault: " + err.Error())
+			}
+		}
+	}
+	for _, fault := range test.Faults {
+		link, err := testbed.SetLink(fault)
+		if err != nil {
+			restore()
+			return nil, err
+		}
+		previous = append(previous, link)
+	}
+	return restore, nil
+}
+
 //RunValid executes and evaluates the test on all given testbeds and for the given repeat value
-//and also validates the tests configuration
-func (test *Test) RunValid() (bool, error) {
+//and also validates the tests configuration, cancelling the context stops the test's variant
+func (test *Test) RunValid(ctx context.Context) (bool, error) {
 	if valid, err := test.Validate(); !valid && err == nil {
 		return false, fmt.Errorf("test configuration is not valid")
 	} else if !valid && err != nil {
 		return false, err
 	}
 
+	if test.Metrics == nil {
+		test.Metrics = make(map[string]Metrics)
+	}
+	repeats := test.Repeats
+	if repeats == 0 {
+		repeats = 1
+	}
 	for _, testbed := range test.testbeds {
-		result, err := test.variant.Run(testbed, test.VariantConfig)
-		if err != nil {
-			return false, fmt.Errorf("test %s failed to run on %s", test.Name, testbed.Name)
-		}
-		pass, err := test.variant.EvaluateExpression(result, test.Expression)
-		if err != nil {
-			return false, fmt.Errorf("test %s failed on %s", test.Name, testbed.Name)
-		}
-		if !pass {
-			return false, nil
+		for iteration := uint(1); iteration <= repeats; iteration++ {
+			pass, err := test.runOn(ctx, testbed, iteration)
+			if err != nil || !pass {
+				testbed.MarkFailed()
+				return false, err
+			}
 		}
 	}
 	return true, nil
 }
 
+//runOn runs and evaluates the test on a single testbed, capturing packets and applying faults around the run,
+//iterations are counted from 1
+func (test *Test) runOn(ctx context.Context, testbed *testbeds.Testbed, iteration uint) (pass bool, err error) {
+	stop, err := test.startCapture(testbed)
+	if err != nil {
+		return false, fmt.Errorf("test %s failed to start capture on %s: %w", test.Name, testbed.Name, err)
+	}
+	defer func() {
+		test.stopCapture(testbed, iteration, stop, pass && err == nil)
+	}()
+	restore, err := test.applyFaults(testbed)
+	if err != nil {
+		return false, fmt.Errorf("test %s failed to apply faults to %s: %w", test.Name, testbed.Name, err)
+	}
+	start := time.Now()
+	result, err := test.variant.Run(ctx, testbed, test.VariantConfig)
+	restore()
+	metrics := test.Metrics[testbed.Name]
+	if iteration == 1 {
+		metrics.StartedAt = start
+	}
+	metrics.ExecutionTime += time.Since(start)
+	test.Metrics[testbed.Name] = metrics
+	if err != nil {
+		return false, fmt.Errorf("test %s failed to run on %s", test.Name, testbed.Name)
+	}
+	pass, err = test.variant.EvaluateExpression(result, test.Expression)
+	if err != nil {
+		return false, fmt.Errorf("test %s failed on %s", test.Name, testbed.Name)
+	}
+	return pass, nil
+}
+
 //Run executes and evaluates the test on all given testbeds and for the given repeat value
 func (test *Test) Run() (bool, error) {
 
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/willfantom/neat/artifacts"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/types"
+)
+
+//captureTestbed adds a testbed whose captures save a single pcap file
+func captureTestbed(t *testing.T) *testbeds.Testbed {
+	lifecycle := func(ctx context.Context, testbed *testbeds.Testbed) error {
+		return nil
+	}
+	testbeds.Variants["capture-test"] = testbeds.Variant{
+		Name: "capture-test",
+		ValidateConfiguration: func(testbed *testbeds.Testbed) (bool, error) {
+			return true, nil
+		},
+		Create: lifecycle,
+		Start:  lifecycle,
+		Stop:   lifecycle,
+		Remove: lifecycle,
+		HookArguments: func(path string, testbed *testbeds.Testbed) []string {
+			return []string{path}
+		},
+		Operations: testbeds.Operations{
+			testbeds.CapabilityCapture: testbeds.CaptureOperation(func(testbed *testbeds.Testbed, request types.CaptureRequest) (testbeds.StopCapture, error) {
+				return func(dir string) ([]string, error) {
+					path := filepath.Join(dir, "eth0.pcap")
+					return []string{path}, ioutil.WriteFile(path, []byte(time.Now().String()), 0644)
+				}, nil
+			}),
+		},
+	}
+	t.Cleanup(func() {
+		delete(testbeds.Variants, "capture-test")
+	})
+	//testbeds are registered globally so each run needs its own name
+	testbed := &testbeds.Testbed{
+		Name:        fmt.Sprintf("capture-test-%d", time.Now().UnixNano()),
+		VariantName: "capture-test",
+	}
+	if _, err := testbed.Add(); err != nil {
+		t.Fatalf("failed to add testbed: %v", err)
+	}
+	return testbed
+}
+
+func TestRepeatsKeepEveryCapture(t *testing.T) {
+	artifacts.Init(t.TempDir(), "run")
+	testbed := captureTestbed(t)
+	runs := 0
+	variants["count-test"] = Variant{
+		Name: "count-test",
+		ValidateConfiguration: func(config map[string]interface{}) (bool, error) {
+			return true, nil
+		},
+		Run: func(ctx context.Context, testbed *testbeds.Testbed, config map[string]interface{}) (map[string]interface{}, error) {
+			runs++
+			return map[string]interface{}{}, nil
+		},
+		EvaluateExpression: func(result map[string]interface{}, expression string) (bool, error) {
+			return true, nil
+		},
+	}
+	defer delete(variants, "count-test")
+
+	test := &Test{
+		Name:         "repeated",
+		Variant:      "count-test",
+		Repeats:      3,
+		TestbedNames: []string{testbed.Name},
+		Expression:   "true",
+		Capture: &Capture{
+			When:           captureAlways,
+			CaptureRequest: types.CaptureRequest{Interfaces: []string{"eth0"}},
+		},
+	}
+	if pass, err := test.RunValid(context.Background()); !pass || err != nil {
+		t.Fatalf("test did not pass: %v", err)
+	}
+	if runs != 3 {
+		t.Errorf("test ran %d times, expected 3", runs)
+	}
+	saved := test.Metrics[testbed.Name].Artifacts
+	if len(saved) != 3 {
+		t.Fatalf("expected a capture for every repeat, got %v", saved)
+	}
+	for idx, path := range saved {
+		if filepath.Base(filepath.Dir(path)) != fmt.Sprint(idx+1) {
+			t.Errorf("capture %s is not in the directory of repeat %d", path, idx+1)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("capture %s was not saved: %v", path, err)
+		}
+	}
+}
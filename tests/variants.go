@@ -1,19 +1,25 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/tests/exec"
+	"github.com/willfantom/neat/tests/expression"
 	"github.com/willfantom/neat/tests/ping"
+	"github.com/willfantom/neat/tests/vnf"
 )
 
 type Variant struct {
 	Name        string
 	Description string
 
+	Requires []testbeds.Capability
+
 	ValidateConfiguration func(config map[string]interface{}) (bool, error)
 	ValidateExpression    func(expression string) (bool, error)
-	Run                   func(testbed *testbeds.Testbed, config map[string]interface{}) (map[string]interface{}, error)
+	Run                   func(ctx context.Context, testbed *testbeds.Testbed, config map[string]interface{}) (map[string]interface{}, error)
 	EvaluateExpression    func(result map[string]interface{}, expression string) (bool, error)
 	EvaluateScript        func(result map[string]interface{}, script string) (bool, error)
 }
@@ -40,8 +46,25 @@ var variants = map[string]Variant{
 	"ping": {
 		Name:                  "Ping",
 		Description:           "Check connectivity between 2 network nodes using ICMP echo packets",
+		Requires:              []testbeds.Capability{testbeds.CapabilityPing},
 		ValidateConfiguration: ping.ValidateConfiguration,
 		Run:                   ping.Run,
-		EvaluateExpression:    ping.Evaluate,
+		EvaluateExpression:    expression.Evaluate,
+	},
+	"exec": {
+		Name:                  "Exec",
+		Description:           "Run a command on a testbed or one of its nodes and check its output",
+		Requires:              []testbeds.Capability{testbeds.CapabilityExec},
+		ValidateConfiguration: exec.ValidateConfiguration,
+		Run:                   exec.Run,
+		EvaluateExpression:    expression.Evaluate,
+	},
+	"vnf": {
+		Name:                  "VNF",
+		Description:           "Start, stop or reboot a VNF and check its state, optionally waiting for it to boot",
+		Requires:              []testbeds.Capability{testbeds.CapabilityVNF},
+		ValidateConfiguration: vnf.ValidateConfiguration,
+		Run:                   vnf.Run,
+		EvaluateExpression:    expression.Evaluate,
 	},
 }
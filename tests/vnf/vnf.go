@@ -0,0 +1,101 @@
+package vnf
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/mitchellh/mapstructure"
+	"github.com/willfantom/neat/testbeds"
+	"github.com/willfantom/neat/types"
+)
+
+const (
+	defaultTimeout  time.Duration = 5 * time.Minute
+	defaultInterval time.Duration = 5 * time.Second
+)
+
+//Config performs an action on a vnf and optionally waits for it to boot afterwards
+type Config struct {
+	VNF      string        `mapstructure:"vnf"`
+	Action   string        `mapstructure:"action"`
+	Wait     bool          `mapstructure:"wait"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+type Result struct {
+	Name     string
+	State    string
+	Booted   bool
+	WaitTime time.Duration
+}
+
+func ValidateConfiguration(config map[string]interface{}) (bool, error) {
+	vnfConfig, err := parseConfig(config)
+	if err != nil {
+		return false, err
+	}
+	if vnfConfig.VNF == "" {
+		return false, errors.New("vnf tests require a vnf name")
+	}
+	switch vnfConfig.Action {
+	case "", types.VNFActionStart, types.VNFActionStop, types.VNFActionReboot:
+	default:
+		return false, errors.New("vnf test action must be start, stop or reboot")
+	}
+	if vnfConfig.Wait && vnfConfig.Action == types.VNFActionStop {
+		return false, errors.New("vnf tests can not wait for a stopped vnf to boot")
+	}
+	if vnfConfig.Timeout <= 0 || vnfConfig.Interval <= 0 {
+		return false, errors.New("vnf test timeout and interval must be positive")
+	}
+	return true, nil
+}
+
+//Run performs the configured action, when waiting for boot a timeout is not an error so that
+//the result can still be evaluated
+func Run(ctx context.Context, testbed *testbeds.Testbed, config map[string]interface{}) (map[string]interface{}, error) {
+	vnfConfig, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	vnf, err := testbed.ControlVNF(vnfConfig.VNF, vnfConfig.Action)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if vnfConfig.Wait {
+		rebooted := vnfConfig.Action == types.VNFActionReboot
+		if booted, err := testbed.WaitForVNF(ctx, vnfConfig.VNF, rebooted, vnfConfig.Timeout, vnfConfig.Interval); booted != nil {
+			vnf = booted
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return structs.Map(Result{
+		Name:     vnf.Name,
+		State:    vnf.State,
+		Booted:   vnf.Booted,
+		WaitTime: time.Since(start),
+	}), nil
+}
+
+func parseConfig(config map[string]interface{}) (*Config, error) {
+	vnfConfig := Config{
+		Timeout:  defaultTimeout,
+		Interval: defaultInterval,
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     &vnfConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(config); err != nil {
+		return nil, err
+	}
+	return &vnfConfig, nil
+}
@@ -2,6 +2,7 @@ package docker
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +17,15 @@ const (
 	neatLabelPrefix string = "neat"
 )
 
+//Resources limits what a container may use, zero values are left unlimited
+type Resources struct {
+	CPUQuota   int64  `mapstructure:"cpu_quota"`
+	CPUPeriod  int64  `mapstructure:"cpu_period"`
+	CPUSetCPUs string `mapstructure:"cpuset_cpus"`
+	Memory     int64  `mapstructure:"memory"`
+	PidsLimit  int64  `mapstructure:"pids_limit"`
+}
+
 type NeatContainer struct {
 	ID          string            `mapstructure:"id"`
 	Name        string            `mapstructure:"name"`
@@ -23,38 +33,42 @@ type NeatContainer struct {
 	Volumes     map[string]string `mapstructure:"volumes"`
 	Labels      map[string]string `mapstructure:"labels"`
 	Environment map[string]string `mapstructure:"environment"`
+	Network     string            `mapstructure:"network"`
 	Privileged  bool              `mapstructure:"privileged"`
 	TTY         bool              `mapstructure:"tty"`
 	Command     []string          `mapstructure:"command"`
+	Resources   Resources         `mapstructure:"resources"`
+	Engine      *Engine           `mapstructure:"-"`
 
 	StartStats []*ContainerStats `mapstructure:"start_stats"`
 	StopStats  []*ContainerStats `mapstructure:"stop_stats"`
+
+	sampler *sampler
 }
 
 func (c *NeatContainer) ImageExists() bool {
 	if c.Image == "" {
 		return false
 	}
-	if _, _, err := docker.ImageInspectWithRaw(ctx, c.Image); err != nil {
-		log.WithField("image", c.Image).Warnln("docker image could not be found")
+	engine, err := c.engine()
+	if err != nil {
+		return false
+	}
+	if _, _, err := engine.client.ImageInspectWithRaw(ctx, c.Image); err != nil {
+		log.WithField("image", c.Image).Debugln("docker image could not be found")
 		return false
 	}
 	return true
 }
 
-func (c *NeatContainer) Pull() error {
-	if c.Image == "" {
-		return fmt.Errorf("no image provided")
+func (c *NeatContainer) Create(ctx context.Context) error {
+	engine, err := c.engine()
+	if err != nil {
+		return err
 	}
-	log.WithField("image", c.Image).Traceln("pulling docker container image")
-	if _, err := docker.ImagePull(ctx, c.Image, types.ImagePullOptions{}); err != nil {
-		log.Errorln(err.Error())
-		return fmt.Errorf("image pull failed")
+	if c.Privileged && engine.Rootless {
+		return fmt.Errorf("privileged containers are not supported by the rootless %s engine at %s, use a rootful engine", engine.Kind, engine.Host)
 	}
-	return nil
-}
-
-func (c *NeatContainer) Create() error {
 	envStrings := make([]string, 0)
 	for name, value := range c.Environment {
 		envStrings = append(envStrings, fmt.Sprintf("%s=%s", name, value))
@@ -76,16 +90,26 @@ func (c *NeatContainer) Create() error {
 		AttachStdin:  c.TTY,
 		AttachStdout: c.TTY,
 	}
+	networkMode := c.Network
+	if networkMode == "" {
+		networkMode = NetworkBridge
+	}
 	hostConfig := &container.HostConfig{
 		Privileged:  c.Privileged,
 		Binds:       volumeBinds,
-		NetworkMode: "bridge",
+		NetworkMode: container.NetworkMode(networkMode),
 		CapAdd:      strslice.StrSlice{"sys_nice"},
-		Resources:   container.Resources{
-			// unstable, will add to configuration space soon
+		Resources: container.Resources{
+			CPUQuota:   c.Resources.CPUQuota,
+			CPUPeriod:  c.Resources.CPUPeriod,
+			CpusetCpus: c.Resources.CPUSetCPUs,
+			Memory:     c.Resources.Memory,
 		},
 	}
-	respose, err := docker.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, c.Name)
+	if c.Resources.PidsLimit > 0 {
+		hostConfig.Resources.PidsLimit = &c.Resources.PidsLimit
+	}
+	respose, err := engine.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, c.Name)
 	if err != nil {
 		log.Errorln(err.Error())
 		return fmt.Errorf("failed to create new container")
@@ -94,11 +118,15 @@ func (c *NeatContainer) Create() error {
 	return nil
 }
 
-func (c *NeatContainer) Start() error {
+func (c *NeatContainer) Start(ctx context.Context) error {
 	if c.ID == "" {
 		return fmt.Errorf("container id needed to start a docker container")
 	}
-	if err := docker.ContainerStart(ctx, c.ID, types.ContainerStartOptions{}); err != nil {
+	engine, err := c.engine()
+	if err != nil {
+		return err
+	}
+	if err := engine.client.ContainerStart(ctx, c.ID, types.ContainerStartOptions{}); err != nil {
 		log.Errorln(err.Error())
 		return fmt.Errorf("failed to start container")
 	}
@@ -110,27 +138,36 @@ func (c *NeatContainer) Start() error {
 	return nil
 }
 
-func (c *NeatContainer) Stop() error {
+func (c *NeatContainer) Stop(ctx context.Context) error {
 	if c.ID == "" {
 		return fmt.Errorf("container id needed to stop a docker container")
 	}
+	engine, err := c.engine()
+	if err != nil {
+		return err
+	}
 	if stats, err := c.Stats(); err != nil {
 		log.WithField("id", c.ID).Warnln("no docker stop stats could be collected")
 	} else {
-		c.StopStats = append(c.StartStats, stats)
+		c.StopStats = append(c.StopStats, stats)
 	}
-	if err := docker.ContainerStop(ctx, c.ID, nil); err != nil {
+	if err := engine.client.ContainerStop(ctx, c.ID, nil); err != nil {
 		log.Errorln(err.Error())
 		return fmt.Errorf("failed to stop container")
 	}
 	return nil
 }
 
-func (c *NeatContainer) Remove() error {
+func (c *NeatContainer) Remove(ctx context.Context) error {
 	if c.ID == "" {
 		return fmt.Errorf("container id needed to remove a docker container")
 	}
-	if err := docker.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{}); err != nil {
+	engine, err := c.engine()
+	if err != nil {
+		return err
+	}
+	//the container may still be running if it was interrupted or failed part way through starting
+	if err := engine.client.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
 		log.Errorln(err.Error())
 		return fmt.Errorf("failed to remove container")
 	}
@@ -138,11 +175,25 @@ func (c *NeatContainer) Remove() error {
 }
 
 func (c *NeatContainer) GetIP() (string, error) {
+	if c.Network == NetworkHost {
+		return "127.0.0.1", nil
+	}
 	container, err := c.inspect()
 	if err != nil {
 		return "", err
 	}
-	return container.NetworkSettings.IPAddress, nil
+	if container.NetworkSettings.IPAddress != "" {
+		return container.NetworkSettings.IPAddress, nil
+	}
+	if network, ok := container.NetworkSettings.Networks[c.Network]; ok && network.IPAddress != "" {
+		return network.IPAddress, nil
+	}
+	for _, network := range container.NetworkSettings.Networks {
+		if network.IPAddress != "" {
+			return network.IPAddress, nil
+		}
+	}
+	return "", fmt.Errorf("docker container has no ip address")
 }
 
 func (c *NeatContainer) Running() (bool, error) {
@@ -154,7 +205,14 @@ func (c *NeatContainer) Running() (bool, error) {
 }
 
 func (c NeatContainer) Stats() (*ContainerStats, error) {
-	stats, err := docker.ContainerStats(ctx, c.ID, false)
+	engine, err := c.engine()
+	if err != nil {
+		return nil, err
+	}
+	if !engine.StatsSupported {
+		return nil, fmt.Errorf("container statistics are not supported by the rootless %s engine at %s without cgroup v2", engine.Kind, engine.Host)
+	}
+	stats, err := engine.client.ContainerStats(ctx, c.ID, false)
 	if err != nil {
 		log.WithField("id", c.ID).Errorln(err.Error())
 		return nil, errors.New("failed to get container statistics")
@@ -181,7 +239,11 @@ func (c NeatContainer) inspect() (*types.ContainerJSON, error) {
 	if c.ID == "" {
 		return nil, fmt.Errorf("container id needed to inspect a docker container")
 	}
-	if container, err := docker.ContainerInspect(ctx, c.ID); err != nil {
+	engine, err := c.engine()
+	if err != nil {
+		return nil, err
+	}
+	if container, err := engine.client.ContainerInspect(ctx, c.ID); err != nil {
 		log.WithField("id", c.ID).Errorln(err.Error())
 		return nil, fmt.Errorf("could not inspect docker container")
 	} else {
@@ -0,0 +1,148 @@
+package docker
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+//CopyTo copies a file or directory from the host into the given directory in the container
+func (c *NeatContainer) CopyTo(hostPath string, containerDir string) error {
+	if c.ID == "" {
+		return fmt.Errorf("container id needed to copy to a docker container")
+	}
+	engine, err := c.engine()
+	if err != nil {
+		return err
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTar(writer, hostPath))
+	}()
+	defer reader.Close()
+	if err := engine.client.CopyToContainer(ctx, c.ID, containerDir, reader, types.CopyToContainerOptions{}); err != nil {
+		log.WithField("id", c.ID).Errorln(err.Error())
+		return errors.New("failed to copy to container")
+	}
+	return nil
+}
+
+//CopyFrom copies a file or directory from the container into the given directory on the host
+func (c *NeatContainer) CopyFrom(containerPath string, hostDir string) error {
+	if c.ID == "" {
+		return fmt.Errorf("container id needed to copy from a docker container")
+	}
+	engine, err := c.engine()
+	if err != nil {
+		return err
+	}
+	content, _, err := engine.client.CopyFromContainer(ctx, c.ID, containerPath)
+	if err != nil {
+		log.WithField("id", c.ID).Errorln(err.Error())
+		return errors.New("failed to copy from container")
+	}
+	defer content.Close()
+	if err := readTar(content, hostDir); err != nil {
+		log.WithField("id", c.ID).Errorln(err.Error())
+		return errors.New("failed to extract files copied from container")
+	}
+	return nil
+}
+
+func writeTar(writer io.Writer, hostPath string) error {
+	archive := tar.NewWriter(writer)
+	base := filepath.Dir(hostPath)
+	err := filepath.Walk(hostPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func readTar(reader io.Reader, hostDir string) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		target := filepath.Join(hostDir, filepath.FromSlash(header.Name))
+		if !withinDir(hostDir, target) {
+			return fmt.Errorf("archive entry '%s' is outside of the destination", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, archive)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			//links that lead out of the destination would let later entries be written through them
+			linkTarget := filepath.FromSlash(header.Linkname)
+			if filepath.IsAbs(linkTarget) || !withinDir(hostDir, filepath.Join(filepath.Dir(target), linkTarget)) {
+				return fmt.Errorf("archive entry '%s' links outside of the destination", header.Name)
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func withinDir(dir string, path string) bool {
+	dir = filepath.Clean(dir)
+	path = filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator))
+}
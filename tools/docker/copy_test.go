@@ -0,0 +1,92 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	content  string
+}
+
+func buildTar(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	var buffer bytes.Buffer
+	archive := tar.NewWriter(&buffer)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+		}
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buffer
+}
+
+func TestReadTarExtractsFilesAndLinks(t *testing.T) {
+	dir := t.TempDir()
+	err := readTar(buildTar(t, []tarEntry{
+		{name: "captures/", typeflag: tar.TypeDir},
+		{name: "captures/h1-eth0.pcap", typeflag: tar.TypeReg, content: "pcap"},
+		{name: "latest", typeflag: tar.TypeSymlink, linkname: "captures/h1-eth0.pcap"},
+	}), dir)
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "latest"))
+	if err != nil || string(content) != "pcap" {
+		t.Errorf("linked file not readable: %q %v", content, err)
+	}
+}
+
+func TestReadTarRejectsEscapes(t *testing.T) {
+	cases := map[string][]tarEntry{
+		"parent entry": {
+			{name: "../escaped", typeflag: tar.TypeReg, content: "x"},
+		},
+		"absolute link": {
+			{name: "link", typeflag: tar.TypeSymlink, linkname: "/tmp"},
+			{name: "link/escaped", typeflag: tar.TypeReg, content: "x"},
+		},
+		"relative link": {
+			{name: "sub/", typeflag: tar.TypeDir},
+			{name: "sub/link", typeflag: tar.TypeSymlink, linkname: "../.."},
+			{name: "sub/link/escaped", typeflag: tar.TypeReg, content: "x"},
+		},
+	}
+	for name, entries := range cases {
+		t.Run(name, func(t *testing.T) {
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "dest")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := readTar(buildTar(t, entries), dir); err == nil {
+				t.Errorf("expected the archive to be rejected")
+			}
+			if _, err := os.Stat(filepath.Join(parent, "escaped")); err == nil {
+				t.Errorf("file was written outside of the destination")
+			}
+		})
+	}
+}
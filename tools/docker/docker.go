@@ -2,17 +2,138 @@ package docker
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/docker/docker/client"
+	"github.com/sirupsen/logrus"
 )
 
+type EngineKind string
+
+const (
+	EngineDocker EngineKind = "docker"
+	EnginePodman EngineKind = "podman"
+)
+
+//Engine is a docker api compatible container engine, such as docker or podman
+type Engine struct {
+	Host           string
+	Kind           EngineKind
+	Version        string
+	Rootless       bool
+	StatsSupported bool
+
+	client *client.Client
+}
+
 var (
-	docker *client.Client
-	ctx    context.Context = context.Background()
+	ctx context.Context = context.Background()
+
+	defaultHost string
+	engines     = make(map[string]*Engine)
+	enginesLock sync.Mutex
 )
 
-func setup() error {
-	var err error
-	docker, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	return err
+//SetDefaultHost sets the engine endpoint used when a container does not give one,
+//if never set DOCKER_HOST is used before looking for common docker and podman sockets
+func SetDefaultHost(host string) {
+	defaultHost = host
+}
+
+//GetEngine connects to the engine at the given endpoint, an empty host gives the default engine
+func GetEngine(host string) (*Engine, error) {
+	if host == "" {
+		host = defaultHost
+	}
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+	if engine, ok := engines[host]; ok {
+		return engine, nil
+	}
+	engine, err := connect(host)
+	if err != nil {
+		return nil, err
+	}
+	engines[host] = engine
+	return engine, nil
+}
+
+func connect(host string) (*Engine, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if host == "" && os.Getenv("DOCKER_HOST") == "" {
+		host = detectHost()
+	}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, err
+	}
+	engine := &Engine{
+		Host:   cli.DaemonHost(),
+		Kind:   EngineDocker,
+		client: cli,
+	}
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach container engine at %s: %w", engine.Host, err)
+	}
+	engine.Version = version.Version
+	for _, component := range version.Components {
+		if strings.Contains(strings.ToLower(component.Name), "podman") {
+			engine.Kind = EnginePodman
+		}
+	}
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get container engine info from %s: %w", engine.Host, err)
+	}
+	for _, option := range info.SecurityOptions {
+		if strings.Contains(option, "rootless") {
+			engine.Rootless = true
+		}
+	}
+	engine.StatsSupported = !engine.Rootless || info.CgroupVersion == "2"
+	log.WithFields(logrus.Fields{
+		"host":     engine.Host,
+		"engine":   engine.Kind,
+		"version":  engine.Version,
+		"rootless": engine.Rootless,
+	}).Debugln("connected to container engine")
+	return engine, nil
+}
+
+//detectHost looks for a docker socket and then podman sockets, giving an empty host if none are found
+func detectHost() string {
+	candidates := []string{"/var/run/docker.sock"}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	candidates = append(candidates, "/run/podman/podman.sock")
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return "unix://" + candidate
+		}
+	}
+	return ""
+}
+
+func (e *Engine) String() string {
+	rootless := ""
+	if e.Rootless {
+		rootless = " (rootless)"
+	}
+	return fmt.Sprintf("%s %s at %s%s", e.Kind, e.Version, e.Host, rootless)
+}
+
+func (c NeatContainer) engine() (*Engine, error) {
+	if c.Engine != nil {
+		return c.Engine, nil
+	}
+	return GetEngine("")
 }
@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+type ExecOptions struct {
+	TTY        bool
+	Timeout    time.Duration
+	Env        map[string]string
+	WorkingDir string
+}
+
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+//Exec runs a command inside the container and waits for it to exit or for the context to be cancelled,
+//when a tty is used all output is given as stdout
+func (c *NeatContainer) Exec(ctx context.Context, command []string, options ExecOptions) (*ExecResult, error) {
+	if c.ID == "" {
+		return nil, fmt.Errorf("container id needed to exec in a docker container")
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no command provided to exec")
+	}
+	engine, err := c.engine()
+	if err != nil {
+		return nil, err
+	}
+	execCtx, cancel := ctx, context.CancelFunc(func() {})
+	if options.Timeout > 0 {
+		execCtx, cancel = context.WithTimeout(ctx, options.Timeout)
+	}
+	defer cancel()
+
+	envStrings := make([]string, 0)
+	for name, value := range options.Env {
+		envStrings = append(envStrings, fmt.Sprintf("%s=%s", name, value))
+	}
+	exec, err := engine.client.ContainerExecCreate(execCtx, c.ID, types.ExecConfig{
+		Cmd:          command,
+		Env:          envStrings,
+		WorkingDir:   options.WorkingDir,
+		Tty:          options.TTY,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		log.WithField("id", c.ID).Errorln(err.Error())
+		return nil, errors.New("failed to create container exec")
+	}
+	attached, err := engine.client.ContainerExecAttach(execCtx, exec.ID, types.ExecStartCheck{Tty: options.TTY})
+	if err != nil {
+		log.WithField("id", c.ID).Errorln(err.Error())
+		return nil, errors.New("failed to start container exec")
+	}
+	defer attached.Close()
+
+	var stdout, stderr bytes.Buffer
+	copied := make(chan error, 1)
+	go func() {
+		if options.TTY {
+			_, err := io.Copy(&stdout, attached.Reader)
+			copied <- err
+		} else {
+			_, err := stdcopy.StdCopy(&stdout, &stderr, attached.Reader)
+			copied <- err
+		}
+	}()
+	select {
+	case err := <-copied:
+		if err != nil {
+			log.WithField("id", c.ID).Errorln(err.Error())
+			return nil, errors.New("failed to read container exec output")
+		}
+	case <-execCtx.Done():
+		return nil, execDone(ctx, options.Timeout)
+	}
+
+	for {
+		inspect, err := engine.client.ContainerExecInspect(execCtx, exec.ID)
+		if err != nil {
+			log.WithField("id", c.ID).Errorln(err.Error())
+			return nil, errors.New("failed to inspect container exec")
+		}
+		if !inspect.Running {
+			return &ExecResult{
+				Stdout:   stdout.String(),
+				Stderr:   stderr.String(),
+				ExitCode: inspect.ExitCode,
+			}, nil
+		}
+		select {
+		case <-execCtx.Done():
+			return nil, execDone(ctx, options.Timeout)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+//execDone gives the error for an exec that was stopped, either by its caller or by its own timeout
+func execDone(ctx context.Context, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("container exec cancelled: %w", err)
+	}
+	return fmt.Errorf("container exec timed out after %s", timeout)
+}
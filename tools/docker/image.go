@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+type PullPolicy string
+
+const (
+	PullAlways       PullPolicy = "always"
+	PullIfNotPresent PullPolicy = "if-not-present"
+	PullNever        PullPolicy = "never"
+)
+
+func (p PullPolicy) Valid() bool {
+	switch p {
+	case PullAlways, PullIfNotPresent, PullNever:
+		return true
+	}
+	return false
+}
+
+type pullMessage struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress string `json:"progress"`
+	Error    *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+//EnsureImage makes sure the container's image is available locally according to the given pull policy
+func (c *NeatContainer) EnsureImage(ctx context.Context, policy PullPolicy) error {
+	switch policy {
+	case PullAlways:
+		return c.Pull(ctx)
+	case PullIfNotPresent, "":
+		if c.ImageExists() {
+			return nil
+		}
+		return c.Pull(ctx)
+	case PullNever:
+		if !c.ImageExists() {
+			return fmt.Errorf("image '%s' is not present and the pull policy is never", c.Image)
+		}
+		return nil
+	default:
+		return fmt.Errorf("pull policy '%s' is not valid", policy)
+	}
+}
+
+//ImageDigest gives the repo digest of the container's image, or the image id if it has no repo digest
+func (c *NeatContainer) ImageDigest() (string, error) {
+	engine, err := c.engine()
+	if err != nil {
+		return "", err
+	}
+	image, _, err := engine.client.ImageInspectWithRaw(ctx, c.Image)
+	if err != nil {
+		log.WithField("image", c.Image).Errorln(err.Error())
+		return "", errors.New("could not inspect docker image")
+	}
+	if len(image.RepoDigests) > 0 {
+		return image.RepoDigests[0], nil
+	}
+	return image.ID, nil
+}
+
+func (c *NeatContainer) Pull(ctx context.Context) error {
+	if c.Image == "" {
+		return fmt.Errorf("no image provided")
+	}
+	engine, err := c.engine()
+	if err != nil {
+		return err
+	}
+	log.WithField("image", c.Image).Infoln("pulling docker container image")
+	stream, err := engine.client.ImagePull(ctx, c.Image, types.ImagePullOptions{})
+	if err != nil {
+		log.Errorln(err.Error())
+		return fmt.Errorf("image pull failed")
+	}
+	defer stream.Close()
+
+	layerStatus := make(map[string]string)
+	decoder := json.NewDecoder(stream)
+	for {
+		var message pullMessage
+		if err := decoder.Decode(&message); err == io.EOF {
+			break
+		} else if err != nil {
+			log.WithField("image", c.Image).Errorln(err.Error())
+			return fmt.Errorf("image pull stream could not be read")
+		}
+		if message.Error != nil {
+			log.WithField("image", c.Image).Errorln(message.Error.Message)
+			return fmt.Errorf("image pull failed: %s", message.Error.Message)
+		}
+		entry := log.WithFields(logrus.Fields{
+			"image": c.Image,
+			"layer": message.ID,
+		})
+		if layerStatus[message.ID] != message.Status {
+			layerStatus[message.ID] = message.Status
+			entry.Infoln(message.Status)
+		} else if message.Progress != "" {
+			entry.Traceln(message.Progress)
+		}
+	}
+	log.WithField("image", c.Image).Infoln("pulled docker container image")
+	return nil
+}
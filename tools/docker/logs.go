@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+//SaveLogs writes everything the container has printed to the given file
+func (c *NeatContainer) SaveLogs(path string) error {
+	if c.ID == "" {
+		return fmt.Errorf("container id needed to collect docker container logs")
+	}
+	engine, err := c.engine()
+	if err != nil {
+		return err
+	}
+	logs, err := engine.client.ContainerLogs(ctx, c.ID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+	})
+	if err != nil {
+		log.WithField("id", c.ID).Errorln(err.Error())
+		return errors.New("failed to get container logs")
+	}
+	defer logs.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if c.TTY {
+		_, err = io.Copy(file, logs)
+	} else {
+		_, err = stdcopy.StdCopy(file, file, logs)
+	}
+	if err != nil {
+		log.WithField("id", c.ID).Errorln(err.Error())
+		return errors.New("failed to write container logs")
+	}
+	return nil
+}
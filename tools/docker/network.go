@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+)
+
+const (
+	NetworkBridge string = "bridge"
+	NetworkHost   string = "host"
+)
+
+//CreateNetwork creates a user defined bridge network and gives its id
+func (e *Engine) CreateNetwork(name string, labels map[string]string) (string, error) {
+	prefixedLabels := make(map[string]string)
+	for label, value := range labels {
+		prefixedLabels[fmt.Sprintf("%s.%s", neatLabelPrefix, label)] = value
+	}
+	response, err := e.client.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         NetworkBridge,
+		Labels:         prefixedLabels,
+	})
+	if err != nil {
+		log.WithField("network", name).Errorln(err.Error())
+		return "", errors.New("failed to create docker network")
+	}
+	if response.Warning != "" {
+		log.WithField("network", name).Warnln(response.Warning)
+	}
+	return response.ID, nil
+}
+
+func (e *Engine) RemoveNetwork(id string) error {
+	if err := e.client.NetworkRemove(ctx, id); err != nil {
+		log.WithField("network", id).Errorln(err.Error())
+		return errors.New("failed to remove docker network")
+	}
+	return nil
+}
+
+//NetworkExists checks for a network with the given name or id
+func (e *Engine) NetworkExists(name string) bool {
+	if _, err := e.client.NetworkInspect(ctx, name, types.NetworkInspectOptions{}); err != nil {
+		log.WithField("network", name).Debugln("docker network could not be found")
+		return false
+	}
+	return true
+}
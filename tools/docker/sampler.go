@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type sampler struct {
+	lock    sync.Mutex
+	samples []*ContainerStats
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+//StartSampling streams the container's statistics in the background, keeping a sample at most every interval
+func (c *NeatContainer) StartSampling(interval time.Duration) error {
+	if c.ID == "" {
+		return errors.New("container id needed to sample a docker container")
+	}
+	if c.sampler != nil {
+		return errors.New("container is already being sampled")
+	}
+	engine, err := c.engine()
+	if err != nil {
+		return err
+	}
+	if !engine.StatsSupported {
+		return fmt.Errorf("container statistics are not supported by the rootless %s engine at %s without cgroup v2", engine.Kind, engine.Host)
+	}
+	samplerCtx, cancel := context.WithCancel(ctx)
+	stats, err := engine.client.ContainerStats(samplerCtx, c.ID, true)
+	if err != nil {
+		cancel()
+		log.WithField("id", c.ID).Errorln(err.Error())
+		return errors.New("failed to stream container statistics")
+	}
+	s := &sampler{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	c.sampler = s
+	go func() {
+		defer close(s.done)
+		defer stats.Body.Close()
+		decoder := json.NewDecoder(stats.Body)
+		var last time.Time
+		for {
+			var containerStats ContainerStats
+			if err := decoder.Decode(&containerStats); err != nil {
+				if samplerCtx.Err() == nil {
+					log.WithField("id", c.ID).Warnln("container statistics stream ended: " + err.Error())
+				}
+				return
+			}
+			if containerStats.ReadTime.Sub(last) < interval {
+				continue
+			}
+			last = containerStats.ReadTime
+			s.lock.Lock()
+			s.samples = append(s.samples, &containerStats)
+			s.lock.Unlock()
+		}
+	}()
+	return nil
+}
+
+//StopSampling ends background sampling and gives all samples collected since it started
+func (c *NeatContainer) StopSampling() []*ContainerStats {
+	if c.sampler == nil {
+		return nil
+	}
+	s := c.sampler
+	c.sampler = nil
+	s.cancel()
+	<-s.done
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.samples
+}
@@ -33,3 +33,29 @@ type ContainerMemory struct {
 type ContainerMemoryStats struct {
 	Cache uint64 `json:"cache"`
 }
+
+//CPUPercent gives the container's cpu usage since the previous read as a percentage of a single cpu
+func (s *ContainerStats) CPUPercent() float64 {
+	containerDelta := float64(s.CPUStats.Usage.Total) - float64(s.PreCPUStats.Usage.Total)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	if containerDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(s.CPUStats.Usage.PerCPU))
+	}
+	return (containerDelta / systemDelta) * cpus * 100
+}
+
+//MemoryPercent gives the container's memory usage, excluding cache, as a percentage of its limit
+func (s *ContainerStats) MemoryPercent() float64 {
+	if s.MemoryStats.Limit == 0 {
+		return 0
+	}
+	usage := s.MemoryStats.Usage
+	if s.MemoryStats.Stats.Cache < usage {
+		usage -= s.MemoryStats.Stats.Cache
+	}
+	return float64(usage) / float64(s.MemoryStats.Limit) * 100
+}
@@ -8,24 +8,25 @@ import (
 var (
 	Tool = tools.Tool{
 		Name:        "Docker",
-		Description: "Interact with a local docker engine via a unix socket",
+		Description: "Interact with a docker api compatible container engine (docker or podman)",
 
 		Check: check,
 	}
-	setupOK bool          = false
-	log     *logrus.Entry = logrus.WithField("tool", "docker")
+	log *logrus.Entry = logrus.WithField("tool", "docker")
 )
 
-func check() bool {
-	if setupOK {
-		return true
-	}
+func check() tools.Status {
 	log.Traceln("checking tool")
-	if err := setup(); err != nil {
-		log.Errorln(err.Error())
-		return false
-	} else {
-		setupOK = true
+	engine, err := GetEngine("")
+	if err != nil {
+		log.Debugln(err.Error())
+		return tools.Status{
+			Reason:      err.Error(),
+			Remediation: "make sure a docker or podman engine is running and reachable, set its endpoint with --engine-host or DOCKER_HOST",
+		}
+	}
+	return tools.Status{
+		Available: true,
+		Version:   string(engine.Kind) + " " + engine.Version,
 	}
-	return true
 }
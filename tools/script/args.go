@@ -0,0 +1,79 @@
+package script
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+//Split breaks a command into its arguments the way a posix shell would, respecting single and
+//double quotes and backslash escapes, but without any expansion
+func Split(command string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, char := range command {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`\n", char) {
+				current.WriteRune('\\')
+			}
+			if !(quote == 0 && char == '\n') {
+				current.WriteRune(char)
+			}
+			escaped = false
+		case quote == '\'':
+			if char == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+		case char == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if char == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+		case char == '\'' || char == '"':
+			quote = char
+			inWord = true
+		case char == ' ' || char == '\t' || char == '\n':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(char)
+			inWord = true
+		}
+	}
+	if escaped && quote == 0 {
+		return nil, fmt.Errorf("command '%s' ends with an unfinished escape", command)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("command '%s' has an unterminated %c quote", command, quote)
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+//StringToArgsHookFunc decodes a string into a string slice using Split, so commands can be given
+//either as a list or as a single string with shell quoting
+func StringToArgsHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.SliceOf(from) {
+			return data, nil
+		}
+		return Split(data.(string))
+	}
+}
@@ -0,0 +1,37 @@
+package script
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	cases := map[string][]string{
+		"ping -c 3 h2":               {"ping", "-c", "3", "h2"},
+		`sh -c "echo a b"`:           {"sh", "-c", "echo a b"},
+		`sh -c 'echo "$HOME"'`:       {"sh", "-c", `echo "$HOME"`},
+		`echo "a \"b\" \n" c\ d`:     {"echo", `a "b" \n`, "c d"},
+		`  spaced   out  `:           {"spaced", "out"},
+		`empty "" ''`:                {"empty", "", ""},
+		`joined"quoted"'parts'plain`: {"joinedquotedpartsplain"},
+		"":                           {},
+	}
+	for command, expected := range cases {
+		args, err := Split(command)
+		if err != nil {
+			t.Errorf("failed to split %q: %v", command, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("split %q into %q, expected %q", command, args, expected)
+		}
+	}
+}
+
+func TestSplitRejectsUnterminated(t *testing.T) {
+	for _, command := range []string{`echo "a`, `echo 'a`, `echo a\`} {
+		if _, err := Split(command); err == nil {
+			t.Errorf("expected %q to be rejected", command)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package script
 
-import "os/exec"
+import (
+	"context"
+	"os/exec"
+)
 
-func Run(args ...string) error {
-	cmd := exec.Command("/bin/sh", args...)
+func Run(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "/bin/sh", args...)
 	if err := cmd.Start(); err != nil {
 		return err
 	}
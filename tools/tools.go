@@ -1,8 +1,45 @@
 package tools
 
+import "fmt"
+
 type Tool struct {
 	Name        string
 	Description string
 
-	Check func() bool
+	Check func() Status
+}
+
+//Status is the outcome of checking if a tool can be used
+type Status struct {
+	Available   bool
+	Version     string
+	Reason      string
+	Remediation string
+}
+
+//UnavailableError describes why a tool can not be used and how that might be fixed
+type UnavailableError struct {
+	Tool   string
+	Status Status
+}
+
+func (e *UnavailableError) Error() string {
+	base := fmt.Sprintf("%s is unavailable", e.Tool)
+	if e.Status.Reason != "" {
+		base = fmt.Sprintf("%s: %s", base, e.Status.Reason)
+	}
+	if e.Status.Remediation != "" {
+		return fmt.Sprintf("%s (hint: %s)", base, e.Status.Remediation)
+	}
+	return base
+}
+
+//Require checks all the given tools, giving an error for the first that is unavailable
+func Require(tools ...Tool) error {
+	for _, tool := range tools {
+		if status := tool.Check(); !status.Available {
+			return &UnavailableError{Tool: tool.Name, Status: status}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,282 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/willfantom/neat/types"
+)
+
+//Graph is a testbed's emulated network, with nodes connected by links
+type Graph struct {
+	Nodes map[string]*types.Node
+	Links []*types.Link
+
+	adjacency map[string][]*types.Link
+}
+
+type NodeNotExistError struct {
+	Node string
+}
+
+func (e *NodeNotExistError) Error() string {
+	return fmt.Sprintf("node '%s' does not exist in the topology", e.Node)
+}
+
+type NoPathError struct {
+	From string
+	To   string
+}
+
+func (e *NoPathError) Error() string {
+	return fmt.Sprintf("no path from '%s' to '%s'", e.From, e.To)
+}
+
+//New builds a graph from a testbed's topology, nodes that links connect to but are not in the
+//topology's nodes (such as vnfs or controllers) are added to the graph with no class
+func New(topology types.Topology) (*Graph, error) {
+	graph := Graph{
+		Nodes:     make(map[string]*types.Node),
+		Links:     make([]*types.Link, 0, len(topology.Links)),
+		adjacency: make(map[string][]*types.Link),
+	}
+	for idx := range topology.Nodes {
+		node := topology.Nodes[idx]
+		graph.Nodes[node.Name] = &node
+	}
+	for idx := range topology.Links {
+		link := topology.Links[idx]
+		for _, name := range []string{link.Node1, link.Node2} {
+			if _, ok := graph.Nodes[name]; !ok {
+				graph.Nodes[name] = &types.Node{Name: name}
+			}
+		}
+		graph.Links = append(graph.Links, &link)
+		graph.adjacency[link.Node1] = append(graph.adjacency[link.Node1], &link)
+		graph.adjacency[link.Node2] = append(graph.adjacency[link.Node2], &link)
+	}
+	return &graph, nil
+}
+
+//Neighbours gives the names of nodes directly connected to the node by links that are up
+func (g *Graph) Neighbours(name string) ([]string, error) {
+	if _, ok := g.Nodes[name]; !ok {
+		return nil, &NodeNotExistError{Node: name}
+	}
+	neighbours := make([]string, 0)
+	for _, link := range g.adjacency[name] {
+		if link.Up {
+			neighbours = append(neighbours, other(link, name))
+		}
+	}
+	sort.Strings(neighbours)
+	return neighbours, nil
+}
+
+//ShortestPath gives the nodes on a path with the fewest hops between the two nodes,
+//only using links that are up
+func (g *Graph) ShortestPath(from, to string) ([]string, error) {
+	if err := g.checkNodes(from, to); err != nil {
+		return nil, err
+	}
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			path := []string{}
+			for node := to; node != ""; node = previous[node] {
+				path = append([]string{node}, path...)
+			}
+			return path, nil
+		}
+		neighbours, _ := g.Neighbours(current)
+		for _, next := range neighbours {
+			if _, seen := previous[next]; !seen {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil, &NoPathError{From: from, To: to}
+}
+
+//Paths gives every path without repeated nodes between the two nodes, shortest first,
+//only using links that are up
+func (g *Graph) Paths(from, to string) ([][]string, error) {
+	if err := g.checkNodes(from, to); err != nil {
+		return nil, err
+	}
+	paths := make([][]string, 0)
+	visited := map[string]bool{from: true}
+	var walk func(path []string)
+	walk = func(path []string) {
+		current := path[len(path)-1]
+		if current == to {
+			paths = append(paths, append([]string{}, path...))
+			return
+		}
+		neighbours, _ := g.Neighbours(current)
+		for _, next := range neighbours {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			walk(append(path, next))
+			visited[next] = false
+		}
+	}
+	walk([]string{from})
+	if len(paths) == 0 {
+		return nil, &NoPathError{From: from, To: to}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return len(paths[i]) < len(paths[j])
+	})
+	return paths, nil
+}
+
+func (g *Graph) checkNodes(names ...string) error {
+	for _, name := range names {
+		if _, ok := g.Nodes[name]; !ok {
+			return &NodeNotExistError{Node: name}
+		}
+	}
+	return nil
+}
+
+//DOT renders the graph in the graphviz dot language, links that are down are dashed
+func (g *Graph) DOT(name string) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "graph %q {\n", name)
+	for _, node := range g.sortedNodes() {
+		fmt.Fprintf(&builder, "  %q [label=%q, shape=%s];\n", node.Name, label(node, "\n"), shape(node))
+	}
+	for _, link := range g.Links {
+		style := ""
+		if !link.Up {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(&builder, "  %q -- %q%s;\n", link.Node1, link.Node2, style)
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+//Mermaid renders the graph as a mermaid flowchart, links that are down are dotted
+func (g *Graph) Mermaid() string {
+	var builder strings.Builder
+	builder.WriteString("graph LR\n")
+	ids := mermaidIDs(g.sortedNodes())
+	for _, node := range g.sortedNodes() {
+		fmt.Fprintf(&builder, "  %s[\"%s\"]\n", ids[node.Name], label(node, "<br/>"))
+	}
+	for _, link := range g.Links {
+		connector := "---"
+		if !link.Up {
+			connector = "-.-"
+		}
+		fmt.Fprintf(&builder, "  %s %s %s\n", ids[link.Node1], connector, ids[link.Node2])
+	}
+	return builder.String()
+}
+
+//JSON renders the graph as the topology it was built from
+func (g *Graph) JSON() (string, error) {
+	topology := types.Topology{
+		Nodes: make([]types.Node, 0, len(g.Nodes)),
+		Links: make([]types.Link, 0, len(g.Links)),
+	}
+	for _, node := range g.sortedNodes() {
+		topology.Nodes = append(topology.Nodes, *node)
+	}
+	for _, link := range g.Links {
+		topology.Links = append(topology.Links, *link)
+	}
+	output, err := json.MarshalIndent(topology, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(output) + "\n", nil
+}
+
+//Render gives the graph in the given format (dot, mermaid or json)
+func (g *Graph) Render(name string, format string) (string, error) {
+	switch format {
+	case "dot":
+		return g.DOT(name), nil
+	case "mermaid":
+		return g.Mermaid(), nil
+	case "json":
+		return g.JSON()
+	default:
+		return "", fmt.Errorf("topology format '%s' is not supported (dot, mermaid or json)", format)
+	}
+}
+
+func (g *Graph) sortedNodes() []*types.Node {
+	nodes := make([]*types.Node, 0, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes
+}
+
+func other(link *types.Link, name string) string {
+	if link.Node1 == name {
+		return link.Node2
+	}
+	return link.Node1
+}
+
+func label(node *types.Node, separator string) string {
+	lines := []string{node.Name}
+	if node.Class != "" {
+		lines = append(lines, node.Class)
+	}
+	lines = append(lines, node.IPs...)
+	return strings.Join(lines, separator)
+}
+
+func shape(node *types.Node) string {
+	class := strings.ToLower(node.Class)
+	switch {
+	case strings.Contains(class, "switch"):
+		return "ellipse"
+	case strings.Contains(class, "controller"):
+		return "diamond"
+	default:
+		return "box"
+	}
+}
+
+//mermaidIDs gives each node a unique id, names that map to the same id are given a numbered suffix
+func mermaidIDs(nodes []*types.Node) map[string]string {
+	ids := make(map[string]string, len(nodes))
+	used := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		id := mermaidID(node.Name)
+		for suffix := 2; used[id]; suffix++ {
+			id = fmt.Sprintf("%s_%d", mermaidID(node.Name), suffix)
+		}
+		used[id] = true
+		ids[node.Name] = id
+	}
+	return ids
+}
+
+//mermaidID gives a node id that is safe to use in mermaid, as names may contain characters it reserves
+func mermaidID(name string) string {
+	return "n_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
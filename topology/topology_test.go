@@ -0,0 +1,82 @@
+package topology
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/willfantom/neat/types"
+)
+
+func TestNewAddsUnknownEndpoints(t *testing.T) {
+	graph, err := New(types.Topology{
+		Nodes: []types.Node{{Name: "h1", Class: "Host"}, {Name: "s1", Class: "OVSSwitch"}},
+		Links: []types.Link{
+			{Node1: "h1", Node2: "s1", Up: true},
+			{Node1: "s1", Node2: "vnf1", Up: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	node, ok := graph.Nodes["vnf1"]
+	if !ok {
+		t.Fatalf("link endpoint was not added as a node")
+	}
+	if node.Class != "" {
+		t.Errorf("unknown endpoint should have no class, got '%s'", node.Class)
+	}
+	path, err := graph.ShortestPath("h1", "vnf1")
+	if err != nil {
+		t.Fatalf("failed to find path: %v", err)
+	}
+	if !reflect.DeepEqual(path, []string{"h1", "s1", "vnf1"}) {
+		t.Errorf("unexpected path %v", path)
+	}
+}
+
+func TestShortestPathSkipsDownLinks(t *testing.T) {
+	graph, err := New(types.Topology{
+		Nodes: []types.Node{{Name: "h1"}, {Name: "h2"}, {Name: "s1"}, {Name: "s2"}},
+		Links: []types.Link{
+			{Node1: "h1", Node2: "h2", Up: false},
+			{Node1: "h1", Node2: "s1", Up: true},
+			{Node1: "s1", Node2: "s2", Up: true},
+			{Node1: "s2", Node2: "h2", Up: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	path, err := graph.ShortestPath("h1", "h2")
+	if err != nil {
+		t.Fatalf("failed to find path: %v", err)
+	}
+	if !reflect.DeepEqual(path, []string{"h1", "s1", "s2", "h2"}) {
+		t.Errorf("unexpected path %v", path)
+	}
+	if _, err := graph.ShortestPath("h1", "h9"); err == nil {
+		t.Errorf("expected an error for a node that does not exist")
+	}
+}
+
+func TestMermaidIDsAreUnique(t *testing.T) {
+	graph, err := New(types.Topology{
+		Nodes: []types.Node{{Name: "h-1"}, {Name: "h_1"}, {Name: "h_1_2"}},
+		Links: []types.Link{{Node1: "h-1", Node2: "h_1", Up: true}},
+	})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	ids := mermaidIDs(graph.sortedNodes())
+	seen := make(map[string]string)
+	for name, id := range ids {
+		if other, ok := seen[id]; ok {
+			t.Errorf("nodes '%s' and '%s' share the id '%s'", name, other, id)
+		}
+		seen[id] = name
+	}
+	if !strings.Contains(graph.Mermaid(), ids["h-1"]+" --- "+ids["h_1"]) {
+		t.Errorf("link is not drawn between the nodes' ids:\n%s", graph.Mermaid())
+	}
+}
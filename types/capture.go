@@ -0,0 +1,15 @@
+package types
+
+//LinkRef names the link between 2 nodes
+type LinkRef struct {
+	Node1 string `mapstructure:"node1" json:"node1"`
+	Node2 string `mapstructure:"node2" json:"node2"`
+}
+
+//CaptureRequest starts a packet capture on each of the given interfaces and links,
+//the filter is given in pcap-filter syntax
+type CaptureRequest struct {
+	Interfaces []string  `mapstructure:"interfaces" json:"interfaces,omitempty"`
+	Links      []LinkRef `mapstructure:"links" json:"links,omitempty"`
+	Filter     string    `mapstructure:"filter" json:"filter,omitempty"`
+}
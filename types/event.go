@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+//EventVNFBooted is recorded when a vnf's operating system has finished starting
+const EventVNFBooted string = "vnf_booted"
+
+//Event is something that happened within a testbed's emulated network
+type Event struct {
+	Time    time.Time `mapstructure:"time" json:"time"`
+	Type    string    `mapstructure:"type" json:"type"`
+	Subject string    `mapstructure:"subject" json:"subject,omitempty"`
+	Detail  string    `mapstructure:"detail" json:"detail,omitempty"`
+}
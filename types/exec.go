@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+type ExecRequest struct {
+	Node    string        `mapstructure:"node" json:"node,omitempty"`
+	Command []string      `mapstructure:"command" json:"command"`
+	TTY     bool          `mapstructure:"tty" json:"tty,omitempty"`
+	Timeout time.Duration `mapstructure:"timeout" json:"timeout,omitempty"`
+}
+
+type ExecResponse struct {
+	Stdout   string `mapstructure:"stdout" json:"stdout"`
+	Stderr   string `mapstructure:"stderr" json:"stderr"`
+	ExitCode int    `mapstructure:"exit_code" json:"exit_code"`
+}
@@ -0,0 +1,74 @@
+package types
+
+//LinkParams are the traffic control parameters of a link, zero values mean the parameter is not applied
+type LinkParams struct {
+	Bandwidth float64 `mapstructure:"bandwidth" json:"bandwidth,omitempty"`
+	Delay     string  `mapstructure:"delay" json:"delay,omitempty"`
+	Jitter    string  `mapstructure:"jitter" json:"jitter,omitempty"`
+	Loss      float64 `mapstructure:"loss" json:"loss,omitempty"`
+}
+
+type Link struct {
+	Node1      string     `mapstructure:"node1" json:"node1"`
+	Node2      string     `mapstructure:"node2" json:"node2"`
+	Interface1 string     `mapstructure:"interface1" json:"interface1,omitempty"`
+	Interface2 string     `mapstructure:"interface2" json:"interface2,omitempty"`
+	Up         bool       `mapstructure:"up" json:"up"`
+	Params     LinkParams `mapstructure:"params" json:"params"`
+}
+
+//LinkRequest changes the state and parameters of the link between 2 nodes,
+//fields that are not given are left unchanged
+type LinkRequest struct {
+	Node1     string   `mapstructure:"node1" json:"node1"`
+	Node2     string   `mapstructure:"node2" json:"node2"`
+	State     string   `mapstructure:"state" json:"state,omitempty"`
+	Bandwidth *float64 `mapstructure:"bandwidth" json:"bandwidth,omitempty"`
+	Delay     *string  `mapstructure:"delay" json:"delay,omitempty"`
+	Jitter    *string  `mapstructure:"jitter" json:"jitter,omitempty"`
+	Loss      *float64 `mapstructure:"loss" json:"loss,omitempty"`
+}
+
+const (
+	LinkStateUp   string = "up"
+	LinkStateDown string = "down"
+)
+
+//HasParams is true if the request changes any of the link's parameters
+func (request LinkRequest) HasParams() bool {
+	return request.Bandwidth != nil || request.Delay != nil || request.Jitter != nil || request.Loss != nil
+}
+
+//Apply gives the link parameters after the request's changes are made to them
+func (params LinkParams) Apply(request LinkRequest) LinkParams {
+	if request.Bandwidth != nil {
+		params.Bandwidth = *request.Bandwidth
+	}
+	if request.Delay != nil {
+		params.Delay = *request.Delay
+	}
+	if request.Jitter != nil {
+		params.Jitter = *request.Jitter
+	}
+	if request.Loss != nil {
+		params.Loss = *request.Loss
+	}
+	return params
+}
+
+//Restore gives a request that returns a link to this state
+func (link Link) Restore() LinkRequest {
+	state := LinkStateDown
+	if link.Up {
+		state = LinkStateUp
+	}
+	return LinkRequest{
+		Node1:     link.Node1,
+		Node2:     link.Node2,
+		State:     state,
+		Bandwidth: &link.Params.Bandwidth,
+		Delay:     &link.Params.Delay,
+		Jitter:    &link.Params.Jitter,
+		Loss:      &link.Params.Loss,
+	}
+}
@@ -0,0 +1,13 @@
+package types
+
+type Node struct {
+	Name  string   `mapstructure:"name" json:"name"`
+	Class string   `mapstructure:"class" json:"class,omitempty"`
+	IPs   []string `mapstructure:"ips" json:"ips,omitempty"`
+	MACs  []string `mapstructure:"macs" json:"macs,omitempty"`
+}
+
+type Topology struct {
+	Nodes []Node `mapstructure:"nodes" json:"nodes"`
+	Links []Link `mapstructure:"links" json:"links"`
+}
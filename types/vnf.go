@@ -0,0 +1,22 @@
+package types
+
+const (
+	VNFActionStart  string = "start"
+	VNFActionStop   string = "stop"
+	VNFActionReboot string = "reboot"
+)
+
+type VNF struct {
+	Name   string   `mapstructure:"name" json:"name"`
+	Domain string   `mapstructure:"domain" json:"domain,omitempty"`
+	State  string   `mapstructure:"state" json:"state"`
+	Booted bool     `mapstructure:"booted" json:"booted"`
+	IPs    []string `mapstructure:"ips" json:"ips,omitempty"`
+}
+
+//VNFRequest performs an action on the named vnf, with no action the vnf's state is only queried
+//and with no name every vnf is queried
+type VNFRequest struct {
+	Name   string `mapstructure:"name" json:"name,omitempty"`
+	Action string `mapstructure:"action" json:"action,omitempty"`
+}